@@ -0,0 +1,165 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type (
+	// Conflict represents a value set differently by two components while
+	// customizing an environment
+	Conflict struct {
+		// Path is the path, into the descriptor, of the conflicting value
+		Path string
+		// Previous is the origin of the value held by the environment before
+		// the customization
+		Previous Origin
+		// Customizing is the origin of the value of the customizing component
+		Customizing Origin
+		// Retained is the value kept by the environment
+		Retained interface{} `json:",omitempty"`
+	}
+)
+
+// CollectConflicts enables the collection of the conflicts occurring while
+// customizing the environment, when two components set the same var,
+// parameter, environment variable or label to different values.
+//
+// If asWarnings is true, the conflicts are also reported as warnings by Validate.
+func (r *Environment) CollectConflicts(asWarnings bool) {
+	r.collectConflicts = true
+	r.conflictWarnings = asWarnings
+}
+
+// Conflicts returns the conflicts collected while customizing the
+// environment, sorted by path, with their sensitive values masked.
+//
+// See CollectConflicts.
+func (r *Environment) Conflicts() []Conflict {
+	return r.redactedConflicts(r.redactor())
+}
+
+// redactedConflicts returns the collected conflicts with their sensitive
+// values masked by the given redactor
+func (r *Environment) redactedConflicts(red redactor) []Conflict {
+	res := make([]Conflict, 0, len(r.conflicts))
+	for _, c := range r.conflicts {
+		c.Previous.Value = red.value(c.Path, c.Previous.Value)
+		c.Customizing.Value = red.value(c.Path, c.Customizing.Value)
+		c.Retained = red.value(c.Path, c.Retained)
+		res = append(res, c)
+	}
+	return res
+}
+
+// conflictsWarnings returns the collected conflicts as validation warnings, located
+// into the descriptor of the customizing component, masked by the given redactor
+func (r *Environment) conflictsWarnings(red redactor) ValidationErrors {
+	vErrs := ValidationErrors{}
+	for _, c := range r.redactedConflicts(red) {
+		retained := c.Customizing
+		if !reflect.DeepEqual(c.Retained, c.Customizing.Value) {
+			retained = c.Previous
+		}
+		message := fmt.Sprintf("conflicting values of %s: %v set by %s, %v set by %s, %v retained",
+			c.Path, c.Previous.Value, c.Previous.Component, c.Customizing.Value, c.Customizing.Component, retained.Value)
+		if c.Customizing.Value == nil {
+			message = fmt.Sprintf("conflicting values of %s: %v set by %s, deleted by %s",
+				c.Path, c.Previous.Value, c.Previous.Component, c.Customizing.Component)
+		}
+		vErrs.Errors = append(vErrs.Errors, ValidationError{
+			ErrorType: Warning,
+			Location:  c.Customizing.Location,
+			Component: c.Customizing.Component,
+			Origins:   []Origin{c.Previous, c.Customizing},
+			Message:   message,
+		})
+	}
+	return vErrs
+}
+
+// collect records the vars, parameters, environment variables and labels set by
+// the customizing component to another value than the one held by the
+// environment.
+//
+// The values deleted by the customizing component, using the "!delete"
+// directive or replacing a map with the "!replace" one, are recorded with a nil
+// customizing value.
+func (r *Environment) collect(from Component, with *Environment, before, after map[string]interface{}) {
+	withLeaves := with.customizingLeaves()
+
+	// The customizing values indexed by their path without directives, along
+	// with the path written into the customizing descriptor
+	type customizing struct {
+		raw   string
+		value interface{}
+	}
+	values := make(map[string]customizing)
+	removed := func(raw string, prefix string) {
+		for path := range before {
+			if _, ok := after[path]; !ok && (path == prefix || strings.HasPrefix(path, prefix+".")) {
+				values[path] = customizing{raw: raw}
+			}
+		}
+	}
+	for raw, wv := range withLeaves {
+		path := withoutDirectives(raw)
+		if !conflictingPath(path) {
+			continue
+		}
+		segments := strings.Split(raw, ".")
+		for i, seg := range segments {
+			if _, d := splitDirective(seg); d == DirectiveDelete || d == DirectiveReplace {
+				prefix := withoutDirectives(strings.Join(segments[:i+1], "."))
+				removed(strings.Join(segments[:i+1], "."), prefix)
+			}
+		}
+		if _, d := splitDirective(segments[len(segments)-1]); d != DirectiveDelete {
+			values[path] = customizing{raw: raw, value: wv}
+		}
+	}
+
+	paths := make([]string, 0)
+	for path, c := range values {
+		bv, ok := before[path]
+		if !ok || reflect.DeepEqual(bv, c.value) {
+			continue
+		}
+		// Only the values discarded in favor of another one are conflicting,
+		// not the merged ones like the concatenated lists
+		av := after[path]
+		if reflect.DeepEqual(av, bv) || reflect.DeepEqual(av, c.value) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		previous, ok := r.provenance.Blame(path)
+		if !ok || previous.Location.Path != path {
+			previous = Origin{Location: r.locate(path)}
+		}
+		previous.Value = before[path]
+		r.conflicts = append(r.conflicts, Conflict{
+			Path:        path,
+			Previous:    previous,
+			Customizing: Origin{Component: from.Id, Location: with.locate(values[path].raw), Value: values[path].value},
+			Retained:    after[path],
+		})
+	}
+}
+
+// conflictingPath returns true if the path locates a var, a parameter, an
+// environment variable or a label
+func conflictingPath(path string) bool {
+	if strings.HasPrefix(path, "vars.") {
+		return true
+	}
+	for _, s := range []string{".params.", ".env.", ".labels."} {
+		if strings.Contains(path, s) {
+			return true
+		}
+	}
+	return false
+}
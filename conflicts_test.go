@@ -0,0 +1,138 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collectConflicts returns the option collecting the conflicts of an environment
+func collectConflicts(asWarnings bool) func(*Environment) {
+	return func(env *Environment) {
+		env.CollectConflicts(asWarnings)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/diff/from.yaml", "./testdata/yaml/diff/to.yaml", collectConflicts(false))
+	assert.Nil(t, err)
+
+	conflicts := env.Conflicts()
+	if assert.Equal(t, 3, len(conflicts)) {
+		c := conflicts[0]
+		assert.Equal(t, "providers.aws.params.region", c.Path)
+		assert.Equal(t, "parent", c.Previous.Component)
+		assert.Equal(t, "eu-west-1", c.Previous.Value)
+		assert.Equal(t, "./testdata/yaml/diff/from.yaml", c.Previous.Location.Descriptor)
+		assert.Equal(t, 29, c.Previous.Location.Line)
+		assert.Equal(t, MainComponentId, c.Customizing.Component)
+		assert.Equal(t, "eu-west-3", c.Customizing.Value)
+		assert.Equal(t, "./testdata/yaml/diff/to.yaml", c.Customizing.Location.Descriptor)
+		assert.Equal(t, 29, c.Customizing.Location.Line)
+		assert.Equal(t, "eu-west-3", c.Retained)
+
+		assert.Equal(t, "stacks.stack1.env.LOG_LEVEL", conflicts[1].Path)
+		assert.Equal(t, "debug", conflicts[1].Retained)
+
+		// The vars already defined take precedence over the plain customizing ones
+		assert.Equal(t, "vars.region", conflicts[2].Path)
+		assert.Equal(t, "eu-west-1", conflicts[2].Retained)
+	}
+
+	// The conflicts are not reported by default
+	vErrs := env.Validate()
+	assert.Equal(t, 0, len(vErrs.locate("conflicting values of vars.region: eu-west-1 set by parent, eu-west-3 set by __main__, eu-west-1 retained")))
+}
+
+func TestConflictsAsWarnings(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/diff/from.yaml", "./testdata/yaml/diff/to.yaml", collectConflicts(true))
+	assert.Nil(t, err)
+
+	vErrs := env.Validate()
+	assert.True(t, vErrs.contains(Warning, "conflicting values of providers.aws.params.region: eu-west-1 set by parent, eu-west-3 set by __main__, eu-west-3 retained", "providers.aws.params.region"))
+	errs := vErrs.locate("conflicting values of vars.region: eu-west-1 set by parent, eu-west-3 set by __main__, eu-west-1 retained")
+	if assert.Equal(t, 1, len(errs)) {
+		assert.Equal(t, MainComponentId, errs[0].Component)
+		assert.Equal(t, "./testdata/yaml/diff/to.yaml", errs[0].Location.Descriptor)
+		assert.Equal(t, 2, len(errs[0].Origins))
+	}
+}
+
+func TestConflictsNotCollected(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/diff/from.yaml", "./testdata/yaml/diff/to.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(env.Conflicts()))
+}
+
+func TestConflictsMasked(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/diff/from.yaml", "./testdata/yaml/diff/to.yaml", collectConflicts(false))
+	assert.Nil(t, err)
+	env.AddSensitiveValues("eu-west-3")
+	conflicts := env.Conflicts()
+	if assert.Equal(t, 3, len(conflicts)) {
+		assert.Equal(t, secretMask, conflicts[0].Customizing.Value)
+		assert.Equal(t, secretMask, conflicts[0].Retained)
+		assert.Equal(t, "eu-west-1", conflicts[0].Previous.Value)
+	}
+}
+
+func TestConflictsDirectives(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/conflicts/parent.yaml", "./testdata/yaml/conflicts/main.yaml", collectConflicts(true))
+	assert.Nil(t, err)
+
+	conflicts := env.Conflicts()
+	paths := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		paths = append(paths, c.Path)
+	}
+	// The list appended with "!append" is merged, not conflicting
+	assert.Equal(t, []string{
+		"nodes.node1.labels.tier",
+		"providers.aws.params.cidrs",
+		"providers.aws.params.debug",
+		"providers.aws.params.limits.cpu",
+		"providers.aws.params.limits.memory",
+		"stacks.stack1.env.LOG_LEVEL",
+		"vars.region",
+		"vars.zone",
+	}, paths)
+	if len(conflicts) != 8 {
+		return
+	}
+
+	// "!delete"
+	assert.Equal(t, "front", conflicts[0].Previous.Value)
+	assert.Nil(t, conflicts[0].Customizing.Value)
+	assert.Nil(t, conflicts[0].Retained)
+	assert.Equal(t, true, conflicts[2].Previous.Value)
+	assert.Nil(t, conflicts[2].Customizing.Value)
+	assert.Equal(t, "./testdata/yaml/conflicts/main.yaml", conflicts[2].Customizing.Location.Descriptor)
+	assert.Equal(t, 17, conflicts[2].Customizing.Location.Line)
+	assert.Equal(t, "debug", conflicts[5].Previous.Value)
+	assert.Nil(t, conflicts[5].Customizing.Value)
+
+	// "!replace"
+	assert.Equal(t, []interface{}{"10.0.0.0/8"}, conflicts[1].Previous.Value)
+	assert.Equal(t, []interface{}{"192.168.0.0/16"}, conflicts[1].Customizing.Value)
+	assert.Equal(t, []interface{}{"192.168.0.0/16"}, conflicts[1].Retained)
+	assert.Equal(t, 13, conflicts[1].Customizing.Location.Line)
+	assert.Equal(t, 4, conflicts[3].Customizing.Value)
+	assert.Equal(t, 4, conflicts[3].Retained)
+	// The keys of the replaced map are deleted
+	assert.Equal(t, "4G", conflicts[4].Previous.Value)
+	assert.Nil(t, conflicts[4].Customizing.Value)
+	assert.Equal(t, 18, conflicts[4].Customizing.Location.Line)
+
+	// The directives of the customizing vars override the vars already defined
+	assert.Equal(t, "eu-west-1", conflicts[6].Previous.Value)
+	assert.Equal(t, "eu-west-3", conflicts[6].Customizing.Value)
+	assert.Equal(t, "eu-west-3", conflicts[6].Retained)
+	assert.Equal(t, "a", conflicts[7].Previous.Value)
+	assert.Nil(t, conflicts[7].Customizing.Value)
+	assert.Nil(t, conflicts[7].Retained)
+
+	vErrs := env.Validate()
+	assert.True(t, vErrs.contains(Warning, "conflicting values of providers.aws.params.debug: true set by parent, deleted by __main__", "providers.aws.params.debug!delete"))
+	assert.True(t, vErrs.contains(Warning, "conflicting values of vars.region: eu-west-1 set by parent, eu-west-3 set by __main__, eu-west-3 retained", "vars.region!replace"))
+	assert.True(t, vErrs.contains(Warning, "conflicting values of vars.zone: a set by parent, deleted by __main__", "vars.zone!delete"))
+}
@@ -26,6 +26,19 @@ func (r Dependencies) inherit(parent Dependencies) Dependencies {
 	return dst
 }
 
+// bind returns a copy of the dependencies referencing the stacks of the given environment
+func (r Dependencies) bind(env *Environment) Dependencies {
+	if r.Content == nil {
+		return r
+	}
+	dst := Dependencies{Content: make([]StackRef, len(r.Content))}
+	for i, v := range r.Content {
+		v.env = env
+		dst.Content[i] = v
+	}
+	return dst
+}
+
 func createDependencies(env *Environment, location DescriptorLocation, dependent string, dependencies []string) Dependencies {
 	res := Dependencies{}
 	for _, v := range dependencies {
@@ -0,0 +1,273 @@
+package model
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type (
+	// ChangeKind represents the kind of a change between two environments
+	ChangeKind int
+
+	// Change represents a difference between two environments
+	Change struct {
+		// Kind represents the kind of the change
+		Kind ChangeKind
+		// Path is the path, into the descriptor, of the changed value or element
+		Path string
+		// From is the original value, nil if the value has been added
+		From interface{} `json:",omitempty"`
+		// To is the new value, nil if the value has been removed
+		To interface{} `json:",omitempty"`
+		// Location is the location of the change into the descriptor
+		// holding the new value, or the original one for removals
+		Location DescriptorLocation
+	}
+
+	// EnvironmentChanges represents all the differences between two environments
+	EnvironmentChanges struct {
+		Changes []Change
+	}
+)
+
+const (
+	//EnvironmentChanged the name, qualifier or description of the environment has changed
+	EnvironmentChanged ChangeKind = iota
+	//VarChanged a descriptor variable has been added, removed or modified
+	VarChanged
+	//ComponentChanged the repository or the ref of a component has changed
+	ComponentChanged
+	//OrchestratorChanged the orchestrator has changed
+	OrchestratorChanged
+	//ProviderAdded a provider has been added
+	ProviderAdded
+	//ProviderRemoved a provider has been removed
+	ProviderRemoved
+	//ProviderChanged a parameter, an environment variable or the proxy of a provider has changed
+	ProviderChanged
+	//NodeSetAdded a node set has been added
+	NodeSetAdded
+	//NodeSetRemoved a node set has been removed
+	NodeSetRemoved
+	//NodeSetResized the number of instances of a node set has changed
+	NodeSetResized
+	//NodeSetChanged the provider or the labels of a node set have changed
+	NodeSetChanged
+	//StackAdded a stack has been added
+	StackAdded
+	//StackRemoved a stack has been removed
+	StackRemoved
+	//StackChanged a parameter, an environment variable or a dependency of a stack has changed
+	StackChanged
+	//TaskAdded a task has been added
+	TaskAdded
+	//TaskRemoved a task has been removed
+	TaskRemoved
+	//TaskChanged a parameter, an environment variable or the playbook of a task has changed
+	TaskChanged
+	//HookChanged the tasks of a hook have changed
+	HookChanged
+)
+
+// String return the name of the given ChangeKind
+func (r ChangeKind) String() string {
+	names := [...]string{
+		"EnvironmentChanged",
+		"VarChanged",
+		"ComponentChanged",
+		"OrchestratorChanged",
+		"ProviderAdded",
+		"ProviderRemoved",
+		"ProviderChanged",
+		"NodeSetAdded",
+		"NodeSetRemoved",
+		"NodeSetResized",
+		"NodeSetChanged",
+		"StackAdded",
+		"StackRemoved",
+		"StackChanged",
+		"TaskAdded",
+		"TaskRemoved",
+		"TaskChanged",
+		"HookChanged"}
+	if r < EnvironmentChanged || r > HookChanged {
+		return "Unknown"
+	}
+	return names[r]
+}
+
+// Diff returns the changes required to go from an environment to another one.
+//
+// The changes are sorted by path. Adding or removing a provider, a node set,
+// a stack or a task is reported as a single change, without the details of its
+// content. A nil environment is handled as an empty one, to get the changes
+// from or to nothing.
+func Diff(from, to *Environment) EnvironmentChanges {
+	if from == nil {
+		from = InitEnvironment()
+	}
+	if to == nil {
+		to = InitEnvironment()
+	}
+	res := EnvironmentChanges{Changes: make([]Change, 0)}
+
+	// Added and removed elements
+	removed := make(map[string]bool)
+	added := make(map[string]bool)
+	diffElements := func(prefix string, fromKeys, toKeys []string, addedKind, removedKind ChangeKind) {
+		for _, k := range fromKeys {
+			if !contains(toKeys, k) {
+				p := prefix + "." + k
+				removed[p] = true
+				res.Changes = append(res.Changes, Change{Kind: removedKind, Path: p, Location: from.locate(p)})
+			}
+		}
+		for _, k := range toKeys {
+			if !contains(fromKeys, k) {
+				p := prefix + "." + k
+				added[p] = true
+				res.Changes = append(res.Changes, Change{Kind: addedKind, Path: p, Location: to.locate(p)})
+			}
+		}
+	}
+	diffElements("providers", from.Providers.names(), to.Providers.names(), ProviderAdded, ProviderRemoved)
+	diffElements("nodes", from.NodeSets.names(), to.NodeSets.names(), NodeSetAdded, NodeSetRemoved)
+	diffElements("stacks", from.Stacks.names(), to.Stacks.names(), StackAdded, StackRemoved)
+	diffElements("tasks", from.Tasks.names(), to.Tasks.names(), TaskAdded, TaskRemoved)
+
+	// Changed values within the remaining elements
+	fromLeaves := from.leaves()
+	toLeaves := to.leaves()
+	within := func(path string, elements map[string]bool) bool {
+		for e := range elements {
+			if strings.HasPrefix(path, e+".") {
+				return true
+			}
+		}
+		return false
+	}
+	for p, fv := range fromLeaves {
+		if within(p, removed) {
+			continue
+		}
+		if tv, ok := toLeaves[p]; !ok {
+			res.Changes = append(res.Changes, Change{Kind: changeKind(p), Path: p, From: fv, Location: from.locate(p)})
+		} else if !reflect.DeepEqual(fv, tv) {
+			res.Changes = append(res.Changes, Change{Kind: changeKind(p), Path: p, From: fv, To: tv, Location: to.locate(p)})
+		}
+	}
+	for p, tv := range toLeaves {
+		if within(p, added) {
+			continue
+		}
+		if _, ok := fromLeaves[p]; !ok {
+			res.Changes = append(res.Changes, Change{Kind: changeKind(p), Path: p, To: tv, Location: to.locate(p)})
+		}
+	}
+
+	// The sensitive values of both environments are masked
+	red := from.redactor().merge(to.redactor())
+	for i, c := range res.Changes {
+		res.Changes[i].From = red.value(c.Path, c.From)
+		res.Changes[i].To = red.value(c.Path, c.To)
+	}
+
+	sort.SliceStable(res.Changes, func(i, j int) bool {
+		return res.Changes[i].Path < res.Changes[j].Path
+	})
+	return res
+}
+
+// changeKind returns the kind of change applied on the value located by the given path
+func changeKind(path string) ChangeKind {
+	segments := strings.Split(path, ".")
+	if segments[0] == "hooks" || (len(segments) > 2 && segments[2] == "hooks" && segments[0] != "vars" && segments[0] != "ekara") {
+		return HookChanged
+	}
+	switch segments[0] {
+	case "vars":
+		return VarChanged
+	case "ekara":
+		return ComponentChanged
+	case "orchestrator":
+		return OrchestratorChanged
+	case "providers":
+		return ProviderChanged
+	case "nodes":
+		if len(segments) == 3 && segments[2] == "instances" {
+			return NodeSetResized
+		}
+		return NodeSetChanged
+	case "stacks":
+		return StackChanged
+	case "tasks":
+		return TaskChanged
+	}
+	return EnvironmentChanged
+}
+
+// HasChanges returns true if at least one change has been detected
+func (r EnvironmentChanges) HasChanges() bool {
+	return len(r.Changes) > 0
+}
+
+// Of returns the changes of the given kind
+func (r EnvironmentChanges) Of(kind ChangeKind) []Change {
+	res := make([]Change, 0)
+	for _, c := range r.Changes {
+		if c.Kind == kind {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// locate returns the location, into the descriptor, of the element located by the path.
+//
+// The location is based on the location of the provider, node set, stack, task
+// or orchestrator containing the element, if any.
+func (r Environment) locate(path string) DescriptorLocation {
+	segments := strings.SplitN(path, ".", 3)
+	rest := func(l DescriptorLocation, from int) DescriptorLocation {
+		if len(segments) > from {
+			return l.appendPath(strings.Join(segments[from:], "."))
+		}
+		return l
+	}
+	if len(segments) > 1 {
+		switch segments[0] {
+		case "providers":
+			if p, ok := r.Providers[segments[1]]; ok && !p.location.empty() {
+				return rest(p.location, 2)
+			}
+		case "nodes":
+			if n, ok := r.NodeSets[segments[1]]; ok && !n.location.empty() {
+				return rest(n.location, 2)
+			}
+		case "stacks":
+			if s, ok := r.Stacks[segments[1]]; ok && !s.location.empty() {
+				return rest(s.location, 2)
+			}
+		case "tasks":
+			if t, ok := r.Tasks[segments[1]]; ok && !t.location.empty() {
+				return rest(t.location, 2)
+			}
+		case "orchestrator":
+			if !r.Orchestrator.location.empty() {
+				return rest(r.Orchestrator.location, 1)
+			}
+		}
+	}
+	return r.location.appendPath(path)
+}
+
+// contains returns true if the slice contains the given string
+func contains(s []string, v string) bool {
+	for _, val := range s {
+		if val == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffNoChange(t *testing.T) {
+	from := buildEnvironment(t, "./testdata/yaml/complete.yaml")
+	to := buildEnvironment(t, "./testdata/yaml/complete.yaml")
+	changes := Diff(from, to)
+	assert.False(t, changes.HasChanges())
+}
+
+func TestDiffFromNil(t *testing.T) {
+	to := buildEnvironment(t, "./testdata/yaml/diff/to.yaml")
+	changes := Diff(nil, to)
+	assert.True(t, changes.HasChanges())
+	assert.True(t, len(changes.Of(NodeSetAdded)) > 0)
+	assert.Equal(t, 0, len(changes.Of(NodeSetRemoved)))
+
+	changes = Diff(to, nil)
+	assert.True(t, len(changes.Of(NodeSetRemoved)) > 0)
+	assert.Equal(t, 0, len(changes.Of(NodeSetAdded)))
+
+	assert.False(t, Diff(nil, nil).HasChanges())
+}
+
+func TestDiff(t *testing.T) {
+	from := buildEnvironment(t, "./testdata/yaml/diff/from.yaml")
+	to := buildEnvironment(t, "./testdata/yaml/diff/to.yaml")
+	changes := Diff(from, to)
+	assert.True(t, changes.HasChanges())
+
+	check := func(kind ChangeKind, path string, fromV, toV interface{}) Change {
+		for _, c := range changes.Of(kind) {
+			if c.Path == path {
+				assert.Equal(t, fromV, c.From, path)
+				assert.Equal(t, toV, c.To, path)
+				return c
+			}
+		}
+		assert.Fail(t, "missing change", "%s %s", kind, path)
+		return Change{}
+	}
+
+	c := check(NodeSetResized, "nodes.node1.instances", 10, 20)
+	assert.Equal(t, "./testdata/yaml/diff/to.yaml", c.Location.Descriptor)
+	assert.Equal(t, 34, c.Location.Line)
+
+	c = check(NodeSetRemoved, "nodes.node2", nil, nil)
+	assert.Equal(t, "./testdata/yaml/diff/from.yaml", c.Location.Descriptor)
+	assert.Equal(t, 37, c.Location.Line)
+	check(NodeSetAdded, "nodes.node3", nil, nil)
+
+	check(ProviderChanged, "providers.aws.params.region", "eu-west-1", "eu-west-3")
+	check(StackChanged, "stacks.stack1.env.LOG_LEVEL", "info", "debug")
+	check(ComponentChanged, "ekara.components.stack1.ref", "1.0.0", "1.1.0")
+	check(VarChanged, "vars.region", "eu-west-1", "eu-west-3")
+	check(VarChanged, "vars.removed_var", "value", nil)
+	check(VarChanged, "vars.added_var", nil, "value")
+	check(HookChanged, "hooks.init.before", []string{"task1"}, nil)
+	check(HookChanged, "hooks.init.after", nil, []string{"task1"})
+
+	// The content of the added and removed node sets is not detailed
+	assert.Equal(t, 1, len(changes.Of(NodeSetAdded)))
+	assert.Equal(t, 1, len(changes.Of(NodeSetRemoved)))
+	assert.Equal(t, 0, len(changes.Of(NodeSetChanged)))
+	assert.Equal(t, 11, len(changes.Changes))
+
+	// The changes are sorted by path
+	for i := 1; i < len(changes.Changes); i++ {
+		assert.True(t, changes.Changes[i-1].Path <= changes.Changes[i].Path)
+	}
+}
+
+func TestChangeKind(t *testing.T) {
+	assert.Equal(t, HookChanged, changeKind("stacks.stack1.hooks.deploy.before"))
+	assert.Equal(t, StackChanged, changeKind("stacks.stack1.params.hooks"))
+	assert.Equal(t, VarChanged, changeKind("vars.a.hooks.b"))
+	assert.Equal(t, NodeSetChanged, changeKind("nodes.node1.labels.instances"))
+	assert.Equal(t, EnvironmentChanged, changeKind("name"))
+	assert.Equal(t, "NodeSetResized", NodeSetResized.String())
+	assert.Equal(t, "Unknown", ChangeKind(-1).String())
+}
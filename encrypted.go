@@ -0,0 +1,307 @@
+package model
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// KeyRing holds the AES-256 keys, of 32 bytes, used to decrypt the
+	// encrypted values of the descriptors.
+	//
+	// The keys are tried in order until one of them decrypts the value.
+	KeyRing [][]byte
+)
+
+// encryptedValue matches the encrypted values, for example:
+//
+//	ENC[AES256_GCM,data:...,iv:...,tag:...,type:str]
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:([A-Za-z0-9+/=]*),iv:([A-Za-z0-9+/=]+),tag:([A-Za-z0-9+/=]+),type:(str|int|float|bool)\]$`)
+
+// isEncryptedValue returns true if the value is an encrypted scalar
+func isEncryptedValue(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, "ENC[")
+}
+
+// EncryptValue encrypts the scalar value with AES-256-GCM, the result can be
+// used in place of the value into the descriptors vars.
+func EncryptValue(key []byte, v interface{}) (string, error) {
+	if len(key) != 32 {
+		return "", errors.New("an AES-256 key of 32 bytes is required")
+	}
+	var t string
+	switch v.(type) {
+	case string:
+		t = "str"
+	case int, int64:
+		t = "int"
+	case float64:
+		t = "float"
+	case bool:
+		t = "bool"
+	default:
+		return "", fmt.Errorf("only scalar values can be encrypted, got %T", v)
+	}
+	gcm, err := secretCipher(key)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, iv, []byte(fmt.Sprintf("%v", v)), nil)
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		t), nil
+}
+
+// Decrypt returns the value decrypted using the first key of the ring able
+// to decrypt it, the value being converted back to its original type.
+func (r KeyRing) Decrypt(v string) (interface{}, error) {
+	m := encryptedValue.FindStringSubmatch(v)
+	if m == nil {
+		return nil, errors.New("invalid encrypted value")
+	}
+	if len(r) == 0 {
+		return nil, errors.New("no key available to decrypt the value")
+	}
+	var parts [3][]byte
+	for i := range parts {
+		b, err := base64.StdEncoding.DecodeString(m[i+1])
+		if err != nil {
+			return nil, errors.New("invalid encrypted value")
+		}
+		parts[i] = b
+	}
+	for _, key := range r {
+		gcm, err := secretCipher(key)
+		if err != nil || len(parts[1]) != gcm.NonceSize() {
+			continue
+		}
+		plain, err := gcm.Open(nil, parts[1], append(parts[0], parts[2]...), nil)
+		if err != nil {
+			continue
+		}
+		s := string(plain)
+		switch m[4] {
+		case "int":
+			return strconv.Atoi(s)
+		case "float":
+			return strconv.ParseFloat(s, 64)
+		case "bool":
+			return strconv.ParseBool(s)
+		}
+		return s, nil
+	}
+	return nil, errors.New("none of the keys can decrypt the value")
+}
+
+// decrypt returns a copy of the value with all its encrypted scalars decrypted,
+// the path locates the value into the errors. The decrypted values are passed
+// to track, if any.
+func (r KeyRing) decrypt(path string, v interface{}, track func(string)) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if isEncryptedValue(val) {
+			res, err := r.Decrypt(val)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decrypt %s: %s", path, err.Error())
+			}
+			if track != nil {
+				track(fmt.Sprintf("%v", res))
+			}
+			return res, nil
+		}
+	case Parameters:
+		return r.decrypt(path, map[string]interface{}(val), track)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for _, k := range sortedKeys(val) {
+			d, err := r.decrypt(path+"."+k, val[k], track)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = d
+		}
+		return res, nil
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(val))
+		keys := make(map[string]interface{}, len(val))
+		for k := range val {
+			keys[fmt.Sprintf("%v", k)] = k
+		}
+		for _, s := range sortedKeys(keys) {
+			k := keys[s]
+			d, err := r.decrypt(path+"."+s, val[k], track)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = d
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, 0, len(val))
+		for i, e := range val {
+			d, err := r.decrypt(fmt.Sprintf("%s[%d]", path, i), e, track)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, d)
+		}
+		return res, nil
+	}
+	return v, nil
+}
+
+// Decrypt returns a copy of the parameters with all their encrypted values
+// decrypted with the key ring
+func (r Parameters) Decrypt(ring KeyRing) (Parameters, error) {
+	res := make(Parameters, len(r))
+	for _, k := range sortedKeys(r) {
+		d, err := ring.decrypt(k, r[k], nil)
+		if err != nil {
+			return r, err
+		}
+		res[k] = d
+	}
+	return res, nil
+}
+
+// decryptDescriptorValues returns the templated descriptor content with the
+// encrypted values of the vars, and of the parameters, the environment
+// variables and the authentication parameters of all the elements, decrypted
+// with the key ring of the context.
+//
+// The content is returned untouched if it doesn't hold any encrypted value.
+func decryptDescriptorValues(content []byte, context *TemplateContext) ([]byte, error) {
+	if !bytes.Contains(content, []byte("ENC[")) {
+		return content, nil
+	}
+	return transformDescriptorValues(content, func(path string, s string) (interface{}, bool, error) {
+		if !isEncryptedValue(s) {
+			return s, false, nil
+		}
+		v, err := context.decrypt(path, s)
+		return v, err == nil, err
+	})
+}
+
+// EncryptDescriptorVar returns the descriptor content with the value of the
+// var located by the given path, like "db.password", encrypted in place.
+//
+// Only the vars having a scalar value defined on the same line than their
+// key, outside of any flow mapping or sequence, can be encrypted, the rest of
+// the content remains unchanged.
+func EncryptDescriptorVar(content []byte, path string, key []byte) ([]byte, error) {
+	fullPath := "vars." + path
+	positions := readYamlPositions(content)
+	p, ok := positions[fullPath]
+	if !ok {
+		return nil, fmt.Errorf("unable to find %s into the descriptor", fullPath)
+	}
+	lines := strings.Split(string(content), "\n")
+	if inYamlFlow(lines, positions, fullPath) {
+		return nil, fmt.Errorf("%s is defined into a flow collection and cannot be encrypted", fullPath)
+	}
+	line := strings.TrimRight(lines[p.line-1], " \t\r")
+	rest := line[p.column-1:]
+	_, raw, ok := splitYamlKey(rest)
+	raw, comment := splitYamlComment(raw)
+	if !ok || raw == "" {
+		return nil, fmt.Errorf("%s has no value to encrypt", fullPath)
+	}
+	if strings.HasPrefix(raw, "|") || strings.HasPrefix(raw, ">") || strings.HasPrefix(raw, "{") ||
+		strings.HasPrefix(raw, "[") || strings.Contains(raw, "{{") {
+		return nil, fmt.Errorf("%s must be a plain scalar to be encrypted", fullPath)
+	}
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(raw), &v); err != nil {
+		return nil, fmt.Errorf("%s must be a plain scalar to be encrypted: %s", fullPath, err.Error())
+	}
+	if isEncryptedValue(v) {
+		return nil, fmt.Errorf("%s is already encrypted", fullPath)
+	}
+	enc, err := EncryptValue(key, v)
+	if err != nil {
+		return nil, err
+	}
+	prefix := rest[:len(rest)-len(raw)-len(comment)]
+	lines[p.line-1] = line[:p.column-1] + strings.TrimRight(prefix, " ") + " " + enc + comment
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// inYamlFlow returns true if one of the parents of the element located by the
+// given path has a flow mapping or sequence as value
+func inYamlFlow(lines []string, positions yamlPositions, path string) bool {
+	for i := 1; i < len(path); i++ {
+		if path[i] != '.' && path[i] != '[' {
+			continue
+		}
+		p, ok := positions[path[:i]]
+		if !ok {
+			continue
+		}
+		rest := strings.TrimRight(lines[p.line-1], " \t\r")[p.column-1:]
+		if strings.HasPrefix(rest, "-") {
+			if isFlowStart(strings.TrimLeft(rest[1:], " ")) {
+				return true
+			}
+			continue
+		}
+		if _, value, ok := splitYamlKey(rest); ok && isFlowStart(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitYamlComment splits a scalar value from the comment following it, the
+// returned comment keeping its leading spaces
+func splitYamlComment(raw string) (string, string) {
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && i > 0 && raw[i-1] == ' ':
+			value := strings.TrimRight(raw[:i], " ")
+			return value, raw[len(value):]
+		}
+	}
+	return raw, ""
+}
+
+// EncryptDescriptorFileVar encrypts in place the value of the var located by
+// the given path into the descriptor file.
+//
+// See EncryptDescriptorVar.
+func EncryptDescriptorFileVar(file string, path string, key []byte) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	res, err := EncryptDescriptorVar(content, path, key)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, res, 0644)
+}
@@ -0,0 +1,184 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+	testOtherKey      = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestEncryptValue(t *testing.T) {
+	ring := KeyRing{testOtherKey, testEncryptionKey}
+	for _, v := range []interface{}{"secret", 12, 1.5, true, ""} {
+		enc, err := EncryptValue(testEncryptionKey, v)
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(enc, "ENC[AES256_GCM,data:"))
+		dec, err := ring.Decrypt(enc)
+		assert.Nil(t, err)
+		assert.Equal(t, v, dec)
+	}
+
+	enc, err := EncryptValue(testEncryptionKey, "secret")
+	assert.Nil(t, err)
+	_, err = KeyRing{testOtherKey}.Decrypt(enc)
+	assert.NotNil(t, err)
+	_, err = KeyRing{}.Decrypt(enc)
+	assert.NotNil(t, err)
+	_, err = KeyRing{testEncryptionKey}.Decrypt("ENC[invalid]")
+	assert.NotNil(t, err)
+
+	_, err = EncryptValue([]byte("short"), "secret")
+	assert.NotNil(t, err)
+	_, err = EncryptValue(testEncryptionKey, []interface{}{"secret"})
+	assert.NotNil(t, err)
+}
+
+func TestParametersDecrypt(t *testing.T) {
+	enc, err := EncryptValue(testEncryptionKey, 42)
+	assert.Nil(t, err)
+	p := CreateParameters(map[string]interface{}{
+		"plain": "value",
+		"nested": map[interface{}]interface{}{
+			"list": []interface{}{enc},
+		},
+	})
+	res, err := p.Decrypt(KeyRing{testEncryptionKey})
+	assert.Nil(t, err)
+	assert.Equal(t, "value", res["plain"])
+	assert.Equal(t, []interface{}{42}, res["nested"].(map[string]interface{})["list"])
+
+	_, err = p.Decrypt(KeyRing{})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "nested.list[0]")
+	}
+}
+
+func TestEncryptedParameters(t *testing.T) {
+	password, err := EncryptValue(testEncryptionKey, "s3cr3t")
+	assert.Nil(t, err)
+	instances, err := EncryptValue(testEncryptionKey, 3)
+	assert.Nil(t, err)
+	content := []byte(`name: encrypted
+providers:
+  aws:
+    component: aws
+    params:
+      password: ` + password + `
+nodes:
+  node1:
+    instances: 1
+    provider:
+      name: aws
+      params:
+        count: ` + instances + `
+stacks:
+  stack1:
+    component: stack1
+    env:
+      PASSWORD: ` + password + `
+`)
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{}))
+	ctx.SetKeyRing(KeyRing{testEncryptionKey})
+	yamlEnv, err := ParseYamlDescriptorBytes("encrypted.yaml", content, ctx)
+	if assert.Nil(t, err) {
+		env, err := CreateEnvironment("encrypted.yaml", yamlEnv, MainComponentId)
+		assert.Nil(t, err)
+		assert.Equal(t, "s3cr3t", env.Providers["aws"].Parameters["password"])
+		assert.Equal(t, 3, env.NodeSets["node1"].Provider.parameters["count"])
+		assert.Equal(t, "s3cr3t", env.Stacks["stack1"].EnvVars["PASSWORD"])
+	}
+	// The decrypted values are kept to be masked
+	assert.Contains(t, ctx.SecretValues(), "s3cr3t")
+	assert.Equal(t, "password: ******", ctx.MaskSecrets("password: s3cr3t"))
+
+	_, err = ParseYamlDescriptorBytes("encrypted.yaml", content, CreateTemplateContext(CreateParameters(map[string]interface{}{})))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "nodes.node1.provider.params.count")
+	}
+}
+
+func TestEncryptedVars(t *testing.T) {
+	content := []byte(`name: encrypted
+vars:
+  db:
+    password: s3cr3t # the production password
+    port: 5432
+  user: "admin"
+providers:
+  aws:
+    component: aws
+    params:
+      password: "{{ .Vars.db.password }}"
+`)
+	encrypted, err := EncryptDescriptorVar(content, "db.password", testEncryptionKey)
+	assert.Nil(t, err)
+	encrypted, err = EncryptDescriptorVar(encrypted, "db.port", testEncryptionKey)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(encrypted), "s3cr3t")
+	assert.NotContains(t, string(encrypted), "5432")
+	lines := strings.Split(string(encrypted), "\n")
+	assert.True(t, strings.HasPrefix(lines[3], "    password: ENC[AES256_GCM,"))
+	// The comment is kept
+	assert.True(t, strings.HasSuffix(lines[3], ",type:str] # the production password"))
+	assert.True(t, strings.HasPrefix(lines[4], "    port: ENC[AES256_GCM,"))
+	assert.Equal(t, strings.Split(string(content), "\n")[5:], lines[5:])
+
+	_, err = EncryptDescriptorVar(encrypted, "db.password", testEncryptionKey)
+	assert.NotNil(t, err)
+	_, err = EncryptDescriptorVar(encrypted, "db", testEncryptionKey)
+	assert.NotNil(t, err)
+	_, err = EncryptDescriptorVar(encrypted, "missing", testEncryptionKey)
+	assert.NotNil(t, err)
+
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{}))
+	ctx.SetKeyRing(KeyRing{testEncryptionKey})
+	yamlEnv, err := ParseYamlDescriptorBytes("encrypted.yaml", encrypted, ctx)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "s3cr3t", yamlEnv.Providers["aws"].Params["password"])
+		db := yamlEnv.Vars["db"].(map[interface{}]interface{})
+		assert.Equal(t, "s3cr3t", db["password"])
+		assert.Equal(t, 5432, db["port"])
+	}
+
+	// Without the key the descriptor cannot be parsed
+	_, err = ParseYamlDescriptorBytes("encrypted.yaml", encrypted, CreateTemplateContext(CreateParameters(map[string]interface{}{})))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "vars.db.password")
+	}
+}
+
+func TestEncryptDescriptorVarIntoFlow(t *testing.T) {
+	tests := map[string]string{
+		"vars: {b: other, a: secretval}\n":                    "a",
+		"vars: {a: secretval, b: other}\n":                    "a",
+		"vars:\n  db: {\n    b: other,\n    a: secretval }\n": "db.a",
+		"vars:\n  list:\n    - {a: secretval}\n":              "list[0].a",
+	}
+	for content, path := range tests {
+		_, err := EncryptDescriptorVar([]byte(content), path, testEncryptionKey)
+		if assert.NotNil(t, err, content) {
+			assert.Equal(t, "vars."+path+" is defined into a flow collection and cannot be encrypted", err.Error())
+		}
+	}
+}
+
+func TestEncryptDescriptorFileVar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encrypted")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "ekara.yaml")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("vars:\n  'key': value\n"), 0644))
+
+	assert.Nil(t, EncryptDescriptorFileVar(file, "key", testEncryptionKey))
+	b, err := ioutil.ReadFile(file)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(b), "vars:\n  'key': ENC[AES256_GCM,"))
+}
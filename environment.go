@@ -42,6 +42,30 @@ type (
 		Volumes GlobalVolumes `yaml:",omitempty"`
 
 		parcels []Parcel
+		// The origins of the values of the customized environment
+		provenance Provenance
+		// The validation errors detected while parsing the descriptors
+		vErrs ValidationErrors
+		// The patterns of the paths declared as sensitive
+		sensitive []string
+		// The values added as sensitive
+		sensitiveValues []string
+		// Indicates if the sensitive values must be shown
+		showSensitive bool
+		// The paths of the elements removed while customizing the environment, like "stacks.monitoring"
+		removed map[string]bool
+		// Indicates if the conflicts must be collected while customizing the environment
+		collectConflicts bool
+		// Indicates if the collected conflicts must be reported as warnings
+		conflictWarnings bool
+		// The conflicts collected while customizing the environment
+		conflicts []Conflict
+		// The paths sealed by the descriptors
+		sealed []sealedPath
+		// Indicates if the overrides of the sealed paths are only reported as warnings
+		warnSealedOverrides bool
+		// The ids of the components which have customized the environment
+		customizedBy []string
 	}
 
 	//Parcel represent an environment intermediate version
@@ -69,11 +93,15 @@ func CreateEnvironment(location string, yamlEnv yamlEnvironment, holder string)
 	env := &Environment{}
 	var err error
 
-	env.location = DescriptorLocation{Descriptor: location}
+	env.location = DescriptorLocation{Descriptor: location, positions: yamlEnv.positions}
 	env.Name = yamlEnv.Name
 	env.Qualifier = yamlEnv.Qualifier
 	env.Description = yamlEnv.Description
 	env.Vars = CreateParameters(yamlEnv.yamlVars.Vars)
+	env.vErrs.merge(yamlEnv.vErrs)
+	env.sensitive = yamlEnv.Sensitive
+	env.AddSensitiveValues(yamlEnv.sensitiveValues...)
+	env.sealed = createSealedPaths(env.location.appendPath("sealed"), yamlEnv.Sealed)
 
 	env.Tasks, err = createTasks(env, env.location.appendPath("tasks"), &yamlEnv)
 	if err != nil {
@@ -119,6 +147,7 @@ func CreateEnvironment(location string, yamlEnv yamlEnvironment, holder string)
 		return env, err
 	}
 	env.Volumes = createGlobalVolumes(env, env.location.appendPath("volumes"), &yamlEnv)
+	env.vErrs.merge(env.directiveErrors())
 	return env, nil
 
 }
@@ -128,11 +157,29 @@ func CreateEnvironment(location string, yamlEnv yamlEnvironment, holder string)
 // Note: basic informations (name, qualifier, description) are only accepted once if the are not already defined
 func (r *Environment) Customize(from Component, with *Environment) error {
 
+	// The parents must be applied from the farthest one, before the main descriptor
+	if err := r.checkCustomizationOrder(from); err != nil {
+		return err
+	}
+
+	// The values sealed by the previous descriptors cannot be overridden
+	if vErrs := r.sealedOverrides(from, with); len(vErrs.Errors) > 0 {
+		if !r.warnSealedOverrides {
+			return vErrs
+		}
+		r.vErrs.merge(vErrs)
+	}
+
 	// We don't want to customize the templates defined into the environment
 	// But instead we want to keep them into the component
 	r.Platform().KeepTemplates(from, with.ekara.Templates)
 	with.ekara.Templates = Patterns{}
 
+	// The parents complete the chain of parents of the platform
+	if with.ekara.HasParent {
+		r.Platform().chainParent(from, with.ekara.Parent)
+	}
+
 	// basic informations (name, qualifier, description) are only accepted once if the are not already defined
 	if r.Name == "" {
 		r.Name = with.Name
@@ -146,6 +193,11 @@ func (r *Environment) Customize(from Component, with *Environment) error {
 		r.Description = with.Description
 	}
 
+	if r.provenance == nil {
+		r.provenance = make(Provenance)
+	}
+	before := r.leaves()
+
 	if err := r.Orchestrator.customize(with.Orchestrator); err != nil {
 		return err
 	}
@@ -174,15 +226,35 @@ func (r *Environment) Customize(from Component, with *Environment) error {
 	}
 	r.Tasks = tas
 
-	r.Vars = r.Vars.inherit(with.Vars)
+	// The vars already defined are kept, unless changed by the merge
+	// directives of the customizing environment
+	r.Vars = r.Vars.keep(with.Vars)
+
+	r.vErrs.merge(with.vErrs)
+
+	for _, p := range with.sensitive {
+		if !contains(r.sensitive, p) {
+			r.sensitive = append(r.sensitive, p)
+		}
+	}
+	r.AddSensitiveValues(with.sensitiveValues...)
+	r.seal(from, with)
 
 	err = r.Hooks.customize(with.Hooks)
 
+	r.applyDirectives()
+	r.bindReferences()
+	if r.collectConflicts {
+		r.collect(from, with, before, r.leaves())
+	}
+	r.provenance.track(from, with, before, r.leaves())
+
 	l, err := lines(*r)
 	if err != nil {
 		return err
 	}
 	r.parcels = append(r.parcels, Parcel{ID: from.Id, Lines: l})
+	r.customizedBy = append(r.customizedBy, from.Id)
 
 	return err
 }
@@ -219,7 +291,55 @@ func (r Environment) Validate() ValidationErrors {
 
 	vErrs.merge(ErrorOnInvalid(r.Tasks))
 	vErrs.merge(ErrorOnInvalid(r.Hooks))
-	return vErrs
+
+	// The errors are reported into the descriptors which have introduced the
+	// invalid content, the parsing errors being already located there
+	res := ValidationErrors{}
+	res.merge(r.vErrs)
+	res.merge(r.provenance.attribute(vErrs))
+	red := r.redactor()
+	if r.conflictWarnings {
+		res.merge(r.conflictsWarnings(red))
+	}
+	return red.errors(res)
+}
+
+// bindReferences makes the references inherited from the customizing
+// environments point to the elements of the receiver, in order to detect the
+// references to removed elements
+func (r *Environment) bindReferences() {
+	for name, n := range r.NodeSets {
+		n.Provider.env = r
+		n.Hooks.Create = n.Hooks.Create.bind(r)
+		n.Hooks.Destroy = n.Hooks.Destroy.bind(r)
+		r.NodeSets[name] = n
+	}
+	for name, s := range r.Stacks {
+		s.DependsOn = s.DependsOn.bind(r)
+		s.Hooks.Deploy = s.Hooks.Deploy.bind(r)
+		r.Stacks[name] = s
+	}
+	for _, t := range r.Tasks {
+		t.Hooks.Execute = t.Hooks.Execute.bind(r)
+	}
+	r.Hooks.Init = r.Hooks.Init.bind(r)
+	r.Hooks.Create = r.Hooks.Create.bind(r)
+	r.Hooks.Install = r.Hooks.Install.bind(r)
+	r.Hooks.Deploy = r.Hooks.Deploy.bind(r)
+	r.Hooks.Destroy = r.Hooks.Destroy.bind(r)
+}
+
+// markRemoved keeps track of an element removed while customizing the environment
+func (r *Environment) markRemoved(kind string, name string) {
+	if r.removed == nil {
+		r.removed = make(map[string]bool)
+	}
+	r.removed[kind+"."+name] = true
+}
+
+// isRemoved returns true if the element has been removed while customizing the environment
+func (r *Environment) isRemoved(kind string, name string) bool {
+	return r != nil && r.removed[kind+"."+name]
 }
 
 //InitEnvironment creates an new Environment
@@ -228,7 +348,8 @@ func InitEnvironment() *Environment {
 		ekara: &Platform{
 			Components: make(map[string]Component),
 		},
-		parcels: make([]Parcel, 0, 0),
+		parcels:    make([]Parcel, 0, 0),
+		provenance: make(Provenance),
 	}
 	env.Orchestrator.cRef.env = env
 	return env
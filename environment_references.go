@@ -4,6 +4,7 @@ type (
 
 	// EnvironmentReferences represents a light Ekara environment, used to unmarshal component references only
 	EnvironmentReferences struct {
+		Version  int `yaml:",omitempty"`
 		Ekara    yamlEkara
 		yamlVars `yaml:",inline"`
 
@@ -98,7 +99,11 @@ func (er EnvironmentReferences) References(owner string) (*ReferencedComponents,
 	return res, nil
 }
 
-// Parent returns the parent of the component
+// Parent returns the direct parent of the component, identified by
+// EkaraComponentId, the only one known without reading the descriptor of the
+// parents.
+//
+// See Parents to get the whole chain of parents.
 func (er EnvironmentReferences) Parent() (Parent, bool, error) {
 	var parentBase Base
 	parentBase, err := CreateBase(er.Ekara.Base)
@@ -1,6 +1,12 @@
 package model
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
 	"gopkg.in/yaml.v2"
 )
 
@@ -23,26 +29,114 @@ func readEnvironmentVars(content []byte) (*yamlEnvironmentVars, error) {
 }
 
 //Fill the TemplateContext with the vars content of the descriptor.
-//The vars content will be templated, using the initial context.
-//Once templated it will be merge into the context
-func (v yamlEnvironmentVars) fillContext(u EkURL, context *TemplateContext) error {
-	varsBytes, err := yaml.Marshal(v)
+//The vars content will be decrypted, using the key ring of the context, and
+//then templated var by var, in the order of their dependencies, each var
+//being able to use the ones it depends on.
+//Once templated, and its secrets resolved, each var will be merge into the context
+func (v yamlEnvironmentVars) fillContext(location DescriptorLocation, context *TemplateContext) error {
+	decrypted, err := context.decrypt("vars", v.Vars)
 	if err != nil {
 		return err
 	}
+	vars, _ := decrypted.(map[string]interface{})
 
-	out, err := ApplyTemplate(u, varsBytes, context)
+	order, err := varsOrder(location, vars)
 	if err != nil {
 		return err
 	}
-	//Parse the templated"vars:" section of the descriptor
-	templated, err := readEnvironmentVars(out.Bytes())
-	if err != nil {
-		return err
+	for _, name := range order {
+		varBytes, err := yaml.Marshal(map[string]interface{}{name: vars[name]})
+		if err != nil {
+			return err
+		}
+		out, err := applyTemplate(location.Descriptor, varBytes, context)
+		if err != nil {
+			return err
+		}
+		//Parse the templated var
+		templated := make(map[string]interface{})
+		if err := yaml.Unmarshal(out.Bytes(), &templated); err != nil {
+			return err
+		}
+		resolved, err := Parameters(templated).ResolveSecrets(context)
+		if err != nil {
+			return err
+		}
+		context.mergeVars(resolved)
+	}
+	return nil
+}
+
+// varsOrder returns the names of the vars sorted in the order of their
+// dependencies, a var depending on the other vars it references.
+//
+// A var referencing itself uses the value coming from the context, so it
+// doesn't depend on itself.
+func varsOrder(location DescriptorLocation, vars map[string]interface{}) ([]string, error) {
+	names := sortedKeys(vars)
+	g := newGraph(len(names))
+	for _, name := range names {
+		g.addNode(name)
+	}
+	for _, name := range names {
+		deps, err := varDependencies(location.Descriptor, vars[name])
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range deps {
+			if d != name {
+				if _, ok := vars[d]; ok {
+					g.addEdge(d, name)
+				}
+			}
+		}
+	}
+	order, ok := g.sort()
+	if ok {
+		return order, nil
 	}
 
-	if len(templated.Vars) > 0 {
-		context.mergeVars(templated.Vars)
+	// The cycle is reported as a chain of references: "a -> b -> a" meaning
+	// that "a" references "b" which references "a"
+	cycle := g.cycle()
+	for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+		cycle[i], cycle[j] = cycle[j], cycle[i]
 	}
-	return nil
+	chain := make([]string, 0, len(cycle))
+	for _, name := range cycle {
+		chain = append(chain, name+" ("+location.appendPath("vars."+name).position()+")")
+	}
+	vErrs := ValidationErrors{}
+	vErrs.addError(fmt.Errorf("cyclic reference between vars: %s", strings.Join(chain, " -> ")), location.appendPath("vars."+cycle[0]))
+	return nil, vErrs
+}
+
+// varDependencies returns the names of the vars referenced by the templates
+// of the given var value
+func varDependencies(name string, v interface{}) ([]string, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := template.New(name).Funcs(templateFuncs(nil)).Parse(string(b))
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	for _, t := range tpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		collectVariables(t.Tree, t.Tree.Root, "", func(path string, n parse.Node) {
+			if segments := strings.Split(path, "."); len(segments) > 2 && segments[1] == "Vars" {
+				found[segments[2]] = true
+			}
+		})
+	}
+	res := make([]string, 0, len(found))
+	for d := range found {
+		res = append(res, d)
+	}
+	sort.Strings(res)
+	return res, nil
 }
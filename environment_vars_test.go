@@ -0,0 +1,74 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVarsDependencyOrder(t *testing.T) {
+	content := []byte(`name: vars
+vars:
+  url: "{{ .Vars.scheme }}://{{ .Vars.host }}:{{ .Vars.port }}"
+  host: "{{ .Vars.name }}.{{ .Vars.domain }}"
+  name: '{{ .Vars.name | default "www" }}'
+  domain: example.com
+  scheme: https
+  port: 443
+  nested:
+    endpoint: "{{ .Vars.url }}/api"
+`)
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{"name": "api"}))
+	yamlEnv, err := ParseYamlDescriptorBytes("vars.yaml", content, ctx)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "https://api.example.com:443", ctx.Vars["url"])
+		assert.Equal(t, "api.example.com", ctx.Vars["host"])
+		nested := ctx.Vars["nested"].(map[string]interface{})
+		assert.Equal(t, "https://api.example.com:443/api", nested["endpoint"])
+		assert.Equal(t, "https://api.example.com:443", yamlEnv.Vars["url"])
+	}
+}
+
+func TestVarsCycle(t *testing.T) {
+	content := []byte(`name: vars
+vars:
+  a: "{{ .Vars.b }}"
+  b: "{{ .Vars.c }}"
+  c: "{{ .Vars.a }}"
+  d: "{{ .Vars.a }}"
+`)
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{}))
+	_, err := ParseYamlDescriptorBytes("vars.yaml", content, ctx)
+	if assert.NotNil(t, err) {
+		vErrs, ok := err.(ValidationErrors)
+		if assert.True(t, ok) && assert.Equal(t, 1, len(vErrs.Errors)) {
+			e := vErrs.Errors[0]
+			assert.Equal(t, "cyclic reference between vars: a (vars.yaml:3:3) -> b (vars.yaml:4:3) -> c (vars.yaml:5:3) -> a (vars.yaml:3:3)", e.Message)
+			assert.Equal(t, "vars.a", e.Location.Path)
+			assert.Equal(t, 3, e.Location.Line)
+		}
+	}
+}
+
+func TestGraphCycle(t *testing.T) {
+	g := newGraph(3)
+	g.addNode("a")
+	g.addNode("b")
+	g.addNode("c")
+	g.addEdge("a", "b")
+	g.addEdge("b", "c")
+	_, ok := g.sort()
+	assert.True(t, ok)
+	assert.Equal(t, []string{}, g.cycle())
+
+	g = newGraph(3)
+	g.addNode("a")
+	g.addNode("b")
+	g.addNode("c")
+	g.addEdge("a", "b")
+	g.addEdge("b", "c")
+	g.addEdge("c", "b")
+	_, ok = g.sort()
+	assert.False(t, ok)
+	assert.Equal(t, []string{"b", "c", "b"}, g.cycle())
+}
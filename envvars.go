@@ -17,12 +17,5 @@ func createEnvVars(src map[string]string) EnvVars {
 }
 
 func (r EnvVars) inherit(parent map[string]string) EnvVars {
-	dst := make(map[string]string)
-	for k, v := range parent {
-		dst[k] = v
-	}
-	for k, v := range r {
-		dst[k] = v
-	}
-	return dst
+	return inheritStrings(r, parent)
 }
@@ -0,0 +1,220 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrSensitiveValuesMasked is returned, along with the exported descriptor,
+// when sensitive values have been masked into it. Such a descriptor cannot be
+// parsed back into the same environment.
+var ErrSensitiveValuesMasked = errors.New("the sensitive values of the exported descriptor are masked")
+
+// ExportDescriptor returns the environment as a self-contained descriptor.
+//
+// The returned descriptor can be parsed back using ParseYamlDescriptor. All the
+// customizations applied on the environment, coming from its parent and from
+// the descriptors of its components, are flattened into the returned content
+// which doesn't reference any parent.
+//
+// The sensitive values are masked unless ShowSensitive has been enabled. When
+// some values have been masked the descriptor doesn't round-trip and is returned
+// along with ErrSensitiveValuesMasked.
+func (r Environment) ExportDescriptor() ([]byte, error) {
+	b, err := yaml.Marshal(r.toYaml())
+	if err != nil || r.showSensitive {
+		return b, err
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(b, &tree); err != nil {
+		return nil, err
+	}
+	redacted := r.redactor().tree("", tree)
+	masked, err := yaml.Marshal(redacted)
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(tree, redacted) {
+		return masked, ErrSensitiveValuesMasked
+	}
+	return masked, nil
+}
+
+// toYaml converts the environment into its yaml representation
+func (r Environment) toYaml() yamlEnvironment {
+	y := yamlEnvironment{
+		Version:     DescriptorVersion,
+		Name:        r.Name,
+		Qualifier:   r.Qualifier,
+		Description: r.Description,
+	}
+	if r.ekara != nil {
+		y.Ekara = r.ekara.toYaml()
+	}
+	y.Vars = r.Vars
+	y.Sensitive = r.sensitive
+	y.Sealed = r.Sealed()
+
+	if len(r.Tasks) > 0 {
+		y.Tasks = make(map[string]yamlTask)
+		for name, t := range r.Tasks {
+			y.Tasks[name] = yamlTask{
+				Component:  t.cRef.ref,
+				yamlParams: yamlParams{Params: t.Parameters},
+				yamlEnv:    yamlEnv{Env: t.EnvVars},
+				Playbook:   t.Playbook,
+				Hooks:      yamlTaskHooks{Execute: t.Hooks.Execute.toYaml()},
+			}
+		}
+	}
+
+	y.Orchestrator = yamlOrchestrator{
+		Component:  r.Orchestrator.cRef.ref,
+		yamlParams: yamlParams{Params: r.Orchestrator.Parameters},
+		yamlEnv:    yamlEnv{Env: r.Orchestrator.EnvVars},
+	}
+
+	if len(r.Providers) > 0 {
+		y.Providers = make(map[string]yamlProvider)
+		for name, p := range r.Providers {
+			y.Providers[name] = yamlProvider{
+				Component:  p.cRef.ref,
+				yamlParams: yamlParams{Params: p.Parameters},
+				yamlEnv:    yamlEnv{Env: p.EnvVars},
+				Proxy:      p.Proxy.toYaml(),
+			}
+		}
+	}
+
+	if len(r.NodeSets) > 0 {
+		y.Nodes = make(map[string]yamlNode)
+		for name, n := range r.NodeSets {
+			y.Nodes[name] = yamlNode{
+				Instances: n.Instances,
+				Provider: yamlProviderRef{
+					Name:       n.Provider.ref,
+					yamlParams: yamlParams{Params: n.Provider.parameters},
+					yamlEnv:    yamlEnv{Env: n.Provider.envVars},
+					Proxy:      n.Provider.proxy.toYaml(),
+				},
+				Hooks:     yamlNodeHooks{Create: n.Hooks.Create.toYaml()},
+				yamlLabel: yamlLabel{Labels: n.Labels},
+			}
+		}
+	}
+
+	if len(r.Stacks) > 0 {
+		y.Stacks = make(map[string]yamlStack)
+		for name, s := range r.Stacks {
+			ys := yamlStack{
+				Component:  s.cRef.ref,
+				Hooks:      yamlStackHooks{Deploy: s.Hooks.Deploy.toYaml()},
+				yamlParams: yamlParams{Params: s.Parameters},
+				yamlEnv:    yamlEnv{Env: s.EnvVars},
+				Playbook:   s.Playbook,
+			}
+			for _, d := range s.DependsOn.Content {
+				ys.DependsOn = append(ys.DependsOn, d.ref)
+			}
+			if len(s.Copies.Content) > 0 {
+				ys.Copies = make(map[string]yamlCopy)
+				for cName, c := range s.Copies.Content {
+					ys.Copies[cName] = yamlCopy{
+						Once:      c.Once,
+						Path:      c.Path,
+						yamlLabel: yamlLabel{Labels: c.Labels},
+						Sources:   c.Sources,
+					}
+				}
+			}
+			y.Stacks[name] = ys
+		}
+	}
+
+	y.Hooks = yamlEnvironmentHooks{
+		Init:    r.Hooks.Init.toYaml(),
+		Create:  r.Hooks.Create.toYaml(),
+		Install: r.Hooks.Install.toYaml(),
+		Deploy:  r.Hooks.Deploy.toYaml(),
+		Delete:  r.Hooks.Destroy.toYaml(),
+	}
+
+	if len(r.Volumes) > 0 {
+		y.Volumes = make(map[string]yamlGlobalVolume)
+		for name, v := range r.Volumes {
+			yv := yamlGlobalVolume{}
+			for _, c := range v.Content {
+				yv.Content = append(yv.Content, yamlVolumeContent{
+					Component: c.Component.ref,
+					Path:      c.Path,
+				})
+			}
+			y.Volumes[name] = yv
+		}
+	}
+	return y
+}
+
+// toYaml converts the platform into its yaml representation, the parent is
+// not exported because its content is expected to be already merged
+func (p Platform) toYaml() yamlEkara {
+	y := yamlEkara{
+		Templates:     p.Templates,
+		yamlPlaybooks: yamlPlaybooks{Playbooks: p.Playbooks},
+	}
+	if p.Base.Url != nil && !p.Base.Defaulted() {
+		y.Base = p.Base.Url.String()
+	}
+	if len(p.Components) > 0 {
+		y.Components = make(map[string]yamlComponent)
+		for id, c := range p.Components {
+			y.Components[id] = c.toYaml()
+		}
+	}
+	return y
+}
+
+// toYaml converts the component into its yaml representation
+func (c Component) toYaml() yamlComponent {
+	y := yamlComponent{
+		Ref:      c.Repository.Ref,
+		yamlAuth: yamlAuth{Auth: c.Repository.Authentication},
+	}
+	if c.Repository.Url != nil {
+		y.Repository = c.Repository.Url.String()
+	}
+	return y
+}
+
+// toYaml converts the hook into its yaml representation
+func (r Hook) toYaml() yamlHook {
+	y := yamlHook{}
+	for _, t := range r.Before {
+		y.Before = append(y.Before, t.toYaml())
+	}
+	for _, t := range r.After {
+		y.After = append(y.After, t.toYaml())
+	}
+	return y
+}
+
+// toYaml converts the task reference into its yaml representation
+func (r TaskRef) toYaml() yamlTaskRef {
+	return yamlTaskRef{
+		Task:       r.ref,
+		Prefix:     r.Prefix,
+		yamlParams: yamlParams{Params: r.parameters},
+		yamlEnv:    yamlEnv{Env: r.envVars},
+	}
+}
+
+// toYaml converts the proxy into its yaml representation
+func (r Proxy) toYaml() yamlProxy {
+	return yamlProxy{
+		Http:    r.Http,
+		Https:   r.Https,
+		NoProxy: r.NoProxy,
+	}
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportDescriptorRoundTrip(t *testing.T) {
+	env := buildEnvironment(t, "./testdata/yaml/complete.yaml")
+
+	b, e := env.ExportDescriptor()
+	assert.Nil(t, e)
+
+	yamlEnv, vErrs, e := ParseYamlDescriptorStrict(buildURL(t, "./testdata/yaml/complete.yaml"), &TemplateContext{}, Error)
+	assert.Nil(t, e)
+	assert.False(t, vErrs.HasErrors())
+
+	// The exported descriptor is strictly valid
+	exported, vErrs, e := parseYamlDescriptor("exported", b, &TemplateContext{}, true, Error)
+	assert.Nil(t, e)
+	assert.False(t, vErrs.HasErrors())
+	assert.Equal(t, DescriptorVersion, exported.Version)
+	assert.Equal(t, yamlEnv.Name, exported.Name)
+	assert.Equal(t, yamlEnv.Qualifier, exported.Qualifier)
+	assert.Equal(t, yamlEnv.Description, exported.Description)
+	// The parent is flattened into the exported descriptor
+	assert.Equal(t, "", exported.Ekara.Parent.Repository)
+
+	reimported, e := CreateEnvironment("exported", exported, MainComponentId)
+	assert.Nil(t, e)
+	p, e := createPlatform(exported.Ekara)
+	assert.Nil(t, e)
+	reimported.ekara = &p
+
+	assert.Equal(t, env.Vars, reimported.Vars)
+	assert.Equal(t, env.Providers["aws"].Parameters, reimported.Providers["aws"].Parameters)
+	assert.Equal(t, env.Providers["aws"].Proxy, reimported.Providers["aws"].Proxy)
+	assert.Equal(t, env.NodeSets["node1"].Instances, reimported.NodeSets["node1"].Instances)
+	assert.Equal(t, env.NodeSets["node1"].Labels, reimported.NodeSets["node1"].Labels)
+	assert.Equal(t, env.Stacks["stack2"].Copies, reimported.Stacks["stack2"].Copies)
+	assert.Equal(t, 2, len(reimported.Hooks.Init.Before))
+	assert.Equal(t, "secondExecution", reimported.Hooks.Init.Before[1].Prefix)
+	assert.Equal(t, 1, len(reimported.Stacks["stack2"].DependsOn.Content))
+
+	// Exporting the reimported environment produces the same descriptor
+	b2, e := reimported.ExportDescriptor()
+	assert.Nil(t, e)
+	assert.Equal(t, string(b), string(b2))
+}
+
+func TestExportDescriptorRepositories(t *testing.T) {
+	env := buildEnvironment(t, "./testdata/yaml/sealed/parent.yaml")
+	b, e := env.ExportDescriptor()
+	assert.Nil(t, e)
+	assert.Contains(t, string(b), "repository: https://github.com/ekara-platform/aws-provider.git\n")
+
+	// The exported repositories, holding their git extension, are parsed back unchanged
+	exported, e := ParseYamlDescriptorBytes("exported", b, &TemplateContext{})
+	assert.Nil(t, e)
+	p, e := createPlatform(exported.Ekara)
+	assert.Nil(t, e)
+	assert.Equal(t, len(env.ekara.Components), len(p.Components))
+	for id, c := range env.ekara.Components {
+		assert.Equal(t, c.Repository.Url.String(), p.Components[id].Repository.Url.String())
+	}
+}
+
+func TestExportDescriptorCustomized(t *testing.T) {
+	parent := buildEnvironment(t, "./testdata/yaml/overwritten/ekara.yaml")
+	env := InitEnvironment()
+	env.ekara = parent.ekara
+	assert.Nil(t, env.Customize(Component{Id: EkaraComponentId}, parent))
+
+	b, e := env.ExportDescriptor()
+	assert.Nil(t, e)
+	content := string(b)
+	assert.True(t, strings.Contains(content, "version: 1"))
+	assert.False(t, strings.Contains(content, "parent:"))
+	assert.True(t, strings.Contains(content, "overwritten_param1"))
+}
+
+func buildEnvironment(t *testing.T, path string) *Environment {
+	yamlEnv, e := ParseYamlDescriptor(buildURL(t, path), &TemplateContext{})
+	assert.Nil(t, e)
+	p, e := createPlatform(yamlEnv.Ekara)
+	assert.Nil(t, e)
+	env, e := CreateEnvironment(path, yamlEnv, MainComponentId)
+	assert.Nil(t, e)
+	env.ekara = &p
+	return env
+}
+
+// customizeWith returns an environment, prepared by the given options,
+// customized by the parent descriptor and then by the main one, along with the
+// error of the customization by the main descriptor.
+func customizeWith(t *testing.T, parent, main string, opts ...func(*Environment)) (*Environment, error) {
+	env := InitEnvironment()
+	for _, opt := range opts {
+		opt(env)
+	}
+	assert.Nil(t, env.Customize(Component{Id: "parent"}, buildEnvironment(t, parent)))
+	return env, env.Customize(Component{Id: MainComponentId}, buildEnvironment(t, main))
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ekara-platform/model"
+)
+
+// Generates the JSON Schema describing the environment descriptor
+func main() {
+	b, err := model.DescriptorSchema()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to generate the descriptor schema: %s\n", err.Error())
+		os.Exit(1)
+	}
+	err = ioutil.WriteFile(model.DescriptorSchemaFile, b, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to write the descriptor schema: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
@@ -3,7 +3,6 @@ module github.com/ekara-platform/model
 go 1.13
 
 require (
-	github.com/json-iterator/go v1.1.9
 	github.com/stretchr/testify v1.3.0
 	gopkg.in/yaml.v2 v2.2.1
 )
@@ -1,5 +1,7 @@
 package model
 
+import "sort"
+
 type graph struct {
 	nodes   []string
 	outputs map[string]map[string]int
@@ -78,3 +80,44 @@ func (g *graph) sort() ([]string, bool) {
 
 	return L, true
 }
+
+// cycle returns a cycle of the graph, as the list of its nodes starting and
+// ending with the same node, or an empty list if the graph has no cycle.
+//
+// It must be called after sort, the remaining edges being the ones between
+// the nodes which cannot be sorted.
+func (g *graph) cycle() []string {
+	visited := make(map[string]bool)
+	path := make([]string, 0)
+	var visit func(n string) []string
+	visit = func(n string) []string {
+		for i, p := range path {
+			if p == n {
+				return append(append([]string{}, path[i:]...), n)
+			}
+		}
+		if visited[n] {
+			return nil
+		}
+		visited[n] = true
+		path = append(path, n)
+		ms := make([]string, 0, len(g.outputs[n]))
+		for m := range g.outputs[n] {
+			ms = append(ms, m)
+		}
+		sort.Strings(ms)
+		for _, m := range ms {
+			if c := visit(m); c != nil {
+				return c
+			}
+		}
+		path = path[:len(path)-1]
+		return nil
+	}
+	for _, n := range g.nodes {
+		if c := visit(n); c != nil {
+			return c
+		}
+	}
+	return []string{}
+}
@@ -51,12 +51,52 @@ func (r Hook) validate() ValidationErrors {
 
 func (r *Hook) customize(with Hook) error {
 	if !reflect.DeepEqual(r, &with) {
-		r.Before = append(r.Before, with.Before...)
-		r.After = append(r.After, with.After...)
+		r.Before = customizeTaskRefs(r.Before, with.Before)
+		r.After = customizeTaskRefs(r.After, with.After)
 	}
 	return nil
 }
 
+// customizeTaskRefs appends the references to the inherited ones, the
+// references flagged for removal dropping the inherited references to
+// the same task
+func customizeTaskRefs(refs []TaskRef, with []TaskRef) []TaskRef {
+	for _, w := range with {
+		if !w.remove {
+			refs = append(refs, w)
+			continue
+		}
+		res := make([]TaskRef, 0, len(refs))
+		for _, r := range refs {
+			if r.ref != w.ref {
+				res = append(res, r)
+			}
+		}
+		refs = res
+	}
+	return refs
+}
+
+// bind returns a copy of the hook referencing the tasks of the given environment
+func (r Hook) bind(env *Environment) Hook {
+	return Hook{
+		Before: bindTaskRefs(r.Before, env),
+		After:  bindTaskRefs(r.After, env),
+	}
+}
+
+func bindTaskRefs(refs []TaskRef, env *Environment) []TaskRef {
+	if refs == nil {
+		return nil
+	}
+	res := make([]TaskRef, len(refs))
+	for i, r := range refs {
+		r.env = env
+		res[i] = r
+	}
+	return res
+}
+
 //HasTasks returns true if the hook contains at least one task reference
 func (r Hook) HasTasks() bool {
 	return len(r.Before) > 0 || len(r.After) > 0
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The types of the descriptor inputs
+const (
+	InputTypeAny    = "any"
+	InputTypeString = "string"
+	InputTypeInt    = "int"
+	InputTypeFloat  = "float"
+	InputTypeBool   = "bool"
+	InputTypeList   = "list"
+	InputTypeMap    = "map"
+)
+
+type (
+	// yaml tag for an input of the descriptor
+	yamlInput struct {
+		// The type of the input, "any" if not specified
+		Type string `yaml:",omitempty"`
+		// The default value of the input
+		Default interface{} `yaml:",omitempty"`
+		// Indicates if the input must be supplied when it has no default value
+		Required bool `yaml:",omitempty"`
+		// The allowed values of the input
+		Values []interface{} `yaml:",omitempty"`
+		// The description of the input
+		Description string `yaml:",omitempty"`
+	}
+
+	// yaml tag for the inputs of the descriptor
+	yamlInputs struct {
+		Inputs map[string]yamlInput `yaml:",omitempty"`
+	}
+)
+
+//Parse just the "inputs:" section of the descriptor
+func readEnvironmentInputs(content []byte) (yamlInputs, error) {
+	res := yamlInputs{}
+	err := yaml.Unmarshal(content, &res)
+	return res, err
+}
+
+// applyDefaults sets into the context the default values of the inputs not
+// supplied
+func (r yamlInputs) applyDefaults(context *TemplateContext) {
+	defaults := make(Parameters)
+	for name, in := range r.Inputs {
+		if _, ok := context.Vars[name]; !ok && in.Default != nil {
+			defaults[name] = in.Default
+		}
+	}
+	if len(defaults) > 0 {
+		context.Vars = context.Vars.inherit(defaults)
+	}
+}
+
+// validate checks the declaration of the inputs and the vars supplied for them
+func (r yamlInputs) validate(location DescriptorLocation, vars Parameters) ValidationErrors {
+	vErrs := ValidationErrors{}
+	for _, name := range r.names() {
+		in := r.Inputs[name]
+		l := location.appendPath("inputs." + name)
+		t := in.Type
+		if t == "" {
+			t = InputTypeAny
+		}
+		if !validInputType(t) {
+			vErrs.addError(fmt.Errorf("unsupported input type: %s", t), l.appendPath("type"))
+			continue
+		}
+		if in.Default != nil {
+			if err := checkInputValue(t, in.Values, in.Default); err != nil {
+				vErrs.addError(fmt.Errorf("invalid default value of input %s: %s", name, err.Error()), l.appendPath("default"))
+			}
+		}
+		v, ok := vars[name]
+		if !ok || v == nil {
+			if in.Required {
+				vErrs.addError(fmt.Errorf("missing required input: %s", name), l)
+			}
+			continue
+		}
+		if err := checkInputValue(t, in.Values, v); err != nil {
+			vErrs.addError(fmt.Errorf("invalid value of input %s: %s", name, err.Error()), l)
+		}
+	}
+	return vErrs
+}
+
+// names returns the names of the inputs in alphabetical order
+func (r yamlInputs) names() []string {
+	return sortedKeys(r.Inputs)
+}
+
+func validInputType(t string) bool {
+	switch t {
+	case InputTypeAny, InputTypeString, InputTypeInt, InputTypeFloat, InputTypeBool, InputTypeList, InputTypeMap:
+		return true
+	}
+	return false
+}
+
+// checkInputValue checks that the value matches the type and, if any, one of the allowed values
+func checkInputValue(t string, values []interface{}, v interface{}) error {
+	ok := true
+	switch t {
+	case InputTypeString:
+		_, ok = v.(string)
+	case InputTypeInt:
+		switch n := v.(type) {
+		case int, int64:
+		case float64:
+			ok = n == float64(int64(n))
+		default:
+			ok = false
+		}
+	case InputTypeFloat:
+		switch v.(type) {
+		case int, int64, float64:
+		default:
+			ok = false
+		}
+	case InputTypeBool:
+		_, ok = v.(bool)
+	case InputTypeList:
+		ok = reflect.ValueOf(v).Kind() == reflect.Slice
+	case InputTypeMap:
+		ok = reflect.ValueOf(v).Kind() == reflect.Map
+	}
+	if !ok {
+		return fmt.Errorf("%s expected, got %s", t, inputValueType(v))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	allowed := make([]string, 0, len(values))
+	for _, a := range values {
+		if reflect.DeepEqual(a, v) || fmt.Sprintf("%v", a) == fmt.Sprintf("%v", v) {
+			return nil
+		}
+		allowed = append(allowed, fmt.Sprintf("%v", a))
+	}
+	return fmt.Errorf("%v is not one of: %s", v, strings.Join(allowed, ", "))
+}
+
+// inputValueType returns the input type matching the value
+func inputValueType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return InputTypeString
+	case int, int64:
+		return InputTypeInt
+	case float64:
+		return InputTypeFloat
+	case bool:
+		return InputTypeBool
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Slice:
+		return InputTypeList
+	case reflect.Map:
+		return InputTypeMap
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseInputs(t *testing.T, vars map[string]interface{}) (yamlEnvironment, error) {
+	content, err := ioutil.ReadFile("./testdata/yaml/inputs/ekara.yaml")
+	assert.Nil(t, err)
+	return ParseYamlDescriptorBytes("ekara.yaml", content, CreateTemplateContext(CreateParameters(vars)))
+}
+
+func TestInputsDefaults(t *testing.T) {
+	yamlEnv, err := parseInputs(t, map[string]interface{}{"region": "eu-west-1"})
+	if assert.Nil(t, err) {
+		params := yamlEnv.Stacks["stack1"].Params
+		assert.Equal(t, "1", params["replicas"])
+		assert.Equal(t, "false", params["debug"])
+		assert.Equal(t, "eu-west-1a", params["zone"])
+		if assert.Equal(t, 4, len(yamlEnv.Inputs)) {
+			assert.Equal(t, "The number of replicas", yamlEnv.Inputs["replicas"].Description)
+		}
+	}
+}
+
+func TestInputsSupplied(t *testing.T) {
+	yamlEnv, err := parseInputs(t, map[string]interface{}{
+		"region":   "eu-west-3",
+		"replicas": 3,
+		"debug":    true,
+		"tags":     []interface{}{"a"},
+	})
+	if assert.Nil(t, err) {
+		params := yamlEnv.Stacks["stack1"].Params
+		assert.Equal(t, "3", params["replicas"])
+		assert.Equal(t, "true", params["debug"])
+	}
+}
+
+func TestInputsInvalid(t *testing.T) {
+	_, err := parseInputs(t, map[string]interface{}{
+		"replicas": "3",
+		"debug":    true,
+		"tags":     "a",
+	})
+	if assert.NotNil(t, err) {
+		vErrs, ok := err.(ValidationErrors)
+		if assert.True(t, ok) && assert.Equal(t, 3, len(vErrs.Errors)) {
+			assert.True(t, vErrs.contains(Error, "missing required input: region", "inputs.region"))
+			assert.True(t, vErrs.contains(Error, "invalid value of input replicas: int expected, got string", "inputs.replicas"))
+			assert.True(t, vErrs.contains(Error, "invalid value of input tags: list expected, got string", "inputs.tags"))
+			assert.Equal(t, 9, vErrs.locate("missing required input: region")[0].Location.Line)
+		}
+	}
+
+	_, err = parseInputs(t, map[string]interface{}{"region": "eu-west-1", "replicas": 2})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid value of input replicas: 2 is not one of: 1, 3, 5")
+	}
+}
+
+func TestInputsDeclaration(t *testing.T) {
+	inputs := yamlInputs{Inputs: map[string]yamlInput{
+		"unknown":    {Type: "number"},
+		"badDefault": {Type: InputTypeBool, Default: "yes"},
+		"any":        {Default: map[interface{}]interface{}{"k": "v"}},
+		"float":      {Type: InputTypeFloat, Default: 1},
+	}}
+	vErrs := inputs.validate(DescriptorLocation{}, Parameters{})
+	assert.Equal(t, 2, len(vErrs.Errors))
+	assert.True(t, vErrs.contains(Error, "unsupported input type: number", "inputs.unknown.type"))
+	assert.True(t, vErrs.contains(Error, "invalid default value of input badDefault: bool expected, got string", "inputs.badDefault.default"))
+}
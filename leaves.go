@@ -0,0 +1,154 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// leaves returns all the values held by the environment, indexed by their
+// path into the descriptor.
+//
+// The parameters and the variables are flattened down to their scalar and
+// list values, the hooks are represented by the names of the referenced
+// tasks.
+func (r Environment) leaves() map[string]interface{} {
+	res := make(map[string]interface{})
+	addLeaf(res, "name", r.Name)
+	addLeaf(res, "qualifier", r.Qualifier)
+	addLeaf(res, "description", r.Description)
+	flattenInto(res, "vars", map[string]interface{}(r.Vars))
+
+	if r.ekara != nil {
+		for id, c := range r.ekara.Components {
+			l := "ekara.components." + id
+			if c.Repository.Url != nil {
+				addLeaf(res, l+".repository", c.Repository.Url.String())
+			}
+			addLeaf(res, l+".ref", c.Repository.Ref)
+		}
+	}
+
+	addLeaf(res, "orchestrator.component", r.Orchestrator.cRef.ref)
+	flattenInto(res, "orchestrator.params", map[string]interface{}(r.Orchestrator.Parameters))
+	flattenEnvVars(res, "orchestrator.env", r.Orchestrator.EnvVars)
+
+	for name, p := range r.Providers {
+		l := "providers." + name
+		addLeaf(res, l+".component", p.cRef.ref)
+		flattenInto(res, l+".params", map[string]interface{}(p.Parameters))
+		flattenEnvVars(res, l+".env", p.EnvVars)
+		flattenProxy(res, l+".proxy", p.Proxy)
+	}
+
+	for name, n := range r.NodeSets {
+		l := "nodes." + name
+		addLeaf(res, l+".instances", n.Instances)
+		addLeaf(res, l+".provider.name", n.Provider.ref)
+		flattenInto(res, l+".provider.params", map[string]interface{}(n.Provider.parameters))
+		flattenEnvVars(res, l+".provider.env", n.Provider.envVars)
+		flattenProxy(res, l+".provider.proxy", n.Provider.proxy)
+		flattenHook(res, l+".hooks.create", n.Hooks.Create)
+		for k, v := range n.Labels {
+			addLeaf(res, l+".labels."+k, v)
+		}
+	}
+
+	for name, s := range r.Stacks {
+		l := "stacks." + name
+		addLeaf(res, l+".component", s.cRef.ref)
+		deps := make([]string, 0, len(s.DependsOn.Content))
+		for _, d := range s.DependsOn.Content {
+			deps = append(deps, d.ref)
+		}
+		addLeaf(res, l+".depends_on", deps)
+		addLeaf(res, l+".playbook", s.Playbook)
+		flattenInto(res, l+".params", map[string]interface{}(s.Parameters))
+		flattenEnvVars(res, l+".env", s.EnvVars)
+		flattenHook(res, l+".hooks.deploy", s.Hooks.Deploy)
+		for k, v := range s.Copies.Content {
+			addLeaf(res, l+".copies."+k, v)
+		}
+	}
+
+	for name, t := range r.Tasks {
+		l := "tasks." + name
+		addLeaf(res, l+".component", t.cRef.ref)
+		addLeaf(res, l+".playbook", t.Playbook)
+		flattenInto(res, l+".params", map[string]interface{}(t.Parameters))
+		flattenEnvVars(res, l+".env", t.EnvVars)
+		flattenHook(res, l+".hooks.execute", t.Hooks.Execute)
+	}
+
+	flattenHook(res, "hooks.init", r.Hooks.Init)
+	flattenHook(res, "hooks.create", r.Hooks.Create)
+	flattenHook(res, "hooks.install", r.Hooks.Install)
+	flattenHook(res, "hooks.deploy", r.Hooks.Deploy)
+	flattenHook(res, "hooks.delete", r.Hooks.Destroy)
+	return res
+}
+
+// addLeaf adds a value, ignoring the empty ones
+func addLeaf(leaves map[string]interface{}, path string, v interface{}) {
+	if v == nil {
+		return
+	}
+	vv := reflect.ValueOf(v)
+	switch vv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		if vv.Len() == 0 {
+			return
+		}
+	case reflect.Int:
+		if vv.Int() == 0 {
+			return
+		}
+	}
+	leaves[path] = v
+}
+
+// flattenInto adds all the scalar and list values of a map, the nested maps
+// are flattened
+func flattenInto(leaves map[string]interface{}, path string, v interface{}) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		for k, val := range m {
+			flattenInto(leaves, path+"."+k, val)
+		}
+	case map[interface{}]interface{}:
+		for k, val := range m {
+			flattenInto(leaves, path+"."+fmt.Sprintf("%v", k), val)
+		}
+	case Parameters:
+		flattenInto(leaves, path, map[string]interface{}(m))
+	default:
+		if v != nil {
+			leaves[path] = v
+		}
+	}
+}
+
+func flattenEnvVars(leaves map[string]interface{}, path string, envVars EnvVars) {
+	for k, v := range envVars {
+		leaves[path+"."+k] = v
+	}
+}
+
+func flattenProxy(leaves map[string]interface{}, path string, p Proxy) {
+	addLeaf(leaves, path+".http_proxy", p.Http)
+	addLeaf(leaves, path+".https_proxy", p.Https)
+	addLeaf(leaves, path+".no_proxy", p.NoProxy)
+}
+
+func flattenHook(leaves map[string]interface{}, path string, h Hook) {
+	addLeaf(leaves, path+".before", hookTasks(h.Before))
+	addLeaf(leaves, path+".after", hookTasks(h.After))
+}
+
+// hookTasks returns the names of the tasks referenced by a hook
+func hookTasks(refs []TaskRef) []string {
+	res := make([]string, 0, len(refs))
+	for _, r := range refs {
+		res = append(res, r.ref)
+	}
+	return res
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 )
 
 type (
@@ -21,6 +22,20 @@ type (
 		// The location of "level3" will be "level1.level2.level3"
 		//
 		Path string
+		//Line is the line, into the original descriptor, of the element
+		//located by the path. If the element itself cannot be located the line
+		//will be the one of its closest parent.
+		//
+		//The line is 0 when unknown.
+		Line int `json:",omitempty"`
+		//Column is the column, into the original descriptor, of the element
+		//located by the path.
+		//
+		//The column is 0 when unknown.
+		Column int `json:",omitempty"`
+
+		// positions of all the elements of the descriptor
+		positions yamlPositions
 	}
 )
 
@@ -33,14 +48,32 @@ func (r DescriptorLocation) empty() bool {
 }
 
 func (r DescriptorLocation) appendPath(suffix string) DescriptorLocation {
-	newLoc := DescriptorLocation{Path: r.Path, Descriptor: r.Descriptor}
+	newLoc := DescriptorLocation{Path: r.Path, Descriptor: r.Descriptor, positions: r.positions}
 	if newLoc.Path == "" {
 		newLoc.Path = suffix
 	} else {
 		newLoc.Path = newLoc.Path + "." + suffix
 	}
-	return newLoc
+	return newLoc.located()
 }
 func (r DescriptorLocation) appendIndex(i int) DescriptorLocation {
-	return DescriptorLocation{Path: r.Path + fmt.Sprintf("[%d]", i), Descriptor: r.Descriptor}
+	return DescriptorLocation{Path: r.Path + fmt.Sprintf("[%d]", i), Descriptor: r.Descriptor, positions: r.positions}.located()
+}
+
+// located returns the location with its line and column resolved
+func (r DescriptorLocation) located() DescriptorLocation {
+	if p, ok := r.positions.locate(r.Path); ok {
+		r.Line = p.line
+		r.Column = p.column
+	}
+	return r
+}
+
+// position returns the descriptor followed by the line and the column of the
+// location, if known, formatted like "descriptor:line:column"
+func (r DescriptorLocation) position() string {
+	if r.Line == 0 {
+		return r.Descriptor
+	}
+	return r.Descriptor + ":" + strconv.Itoa(r.Line) + ":" + strconv.Itoa(r.Column)
 }
@@ -80,3 +80,43 @@ func TestEmpty(t *testing.T) {
 	}
 	assert.True(t, d.empty())
 }
+
+func TestAppendPathLocated(t *testing.T) {
+	d := DescriptorLocation{
+		Descriptor: "desc",
+		positions:  readYamlPositions([]byte("nodes:\n  node1:\n    instances: 1\n")),
+	}
+
+	d1 := d.appendPath("nodes").appendPath("node1").appendPath("instances")
+	assert.Equal(t, 3, d1.Line)
+	assert.Equal(t, 5, d1.Column)
+	assert.Equal(t, "desc:3:5", d1.position())
+
+	// An unknown element is located on its closest parent
+	d2 := d.appendPath("nodes.node1.provider")
+	assert.Equal(t, 2, d2.Line)
+	assert.Equal(t, 3, d2.Column)
+
+	// Nothing can be located
+	d3 := d.appendPath("stacks")
+	assert.Equal(t, 0, d3.Line)
+	assert.Equal(t, 0, d3.Column)
+	assert.Equal(t, "desc", d3.position())
+}
+
+func TestLocationsFromDescriptor(t *testing.T) {
+	yamlEnv, e := ParseYamlDescriptor(buildURL(t, "./testdata/yaml/complete.yaml"), &TemplateContext{})
+	assert.Nil(t, e)
+	env, e := CreateEnvironment("complete.yaml", yamlEnv, MainComponentId)
+	assert.Nil(t, e)
+
+	l := env.NodeSets["node1"].location.appendPath("instances")
+	assert.Equal(t, "nodes.node1.instances", l.Path)
+	assert.Equal(t, 116, l.Line)
+	assert.Equal(t, 5, l.Column)
+
+	l = env.Tasks["task1"].location
+	assert.Equal(t, "tasks.task1", l.Path)
+	assert.Equal(t, 48, l.Line)
+	assert.Equal(t, 3, l.Column)
+}
@@ -0,0 +1,286 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// DirectiveReplace is the key suffix replacing the inherited value instead
+	// of merging it, like in "cidrs!replace"
+	DirectiveReplace = "!replace"
+	// DirectiveAppend is the key suffix appending a list to the inherited one,
+	// as done by default, like in "tags!append"
+	DirectiveAppend = "!append"
+	// DirectiveDelete is the key suffix removing the inherited value, like in
+	// "debug!delete: true"
+	DirectiveDelete = "!delete"
+)
+
+// directives are all the supported merge directives
+var directives = []string{DirectiveReplace, DirectiveAppend, DirectiveDelete}
+
+// splitDirective returns the key without its merge directive and the directive,
+// empty if the key has none
+func splitDirective(key string) (string, string) {
+	for _, d := range directives {
+		if strings.HasSuffix(key, d) && len(key) > len(d) {
+			return strings.TrimSuffix(key, d), d
+		}
+	}
+	return key, ""
+}
+
+// directiveKeys returns the keys of the map in alphabetical order, the keys
+// holding a merge directive being the last ones
+func directiveKeys(keys []string) []string {
+	sort.SliceStable(keys, func(i, j int) bool {
+		_, di := splitDirective(keys[i])
+		_, dj := splitDirective(keys[j])
+		if (di == "") != (dj == "") {
+			return di == ""
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// isDeleted returns true if the value of a "!delete" directive is valid
+func isDeleted(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	b, ok := v.(bool)
+	return ok && b
+}
+
+// inheritStrings merges the parent map of strings with the given one, which
+// take precedence, honouring the "!replace" and "!delete" directives
+func inheritStrings(r map[string]string, parent map[string]string) map[string]string {
+	dst := make(map[string]string)
+	for _, src := range []map[string]string{parent, r} {
+		keys := make([]string, 0, len(src))
+		for k := range src {
+			keys = append(keys, k)
+		}
+		for _, k := range directiveKeys(keys) {
+			name, d := splitDirective(k)
+			switch d {
+			case DirectiveDelete:
+				delete(dst, name)
+			default:
+				dst[name] = src[k]
+			}
+		}
+	}
+	return dst
+}
+
+// keep returns the parameters merged with the customizing ones, the values of
+// the receiver taking precedence over the plain values of the customizing
+// parameters but not over their merge directives, which are honoured.
+func (r Parameters) keep(with Parameters) Parameters {
+	plain, directed := splitDirectiveValues(with)
+	return Parameters(directed).inherit(r.inherit(plain))
+}
+
+// splitDirectiveValues splits the parameters into the plain values and the
+// values suffixed by a merge directive, along with the maps holding them
+func splitDirectiveValues(params map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	plain := make(map[string]interface{})
+	directed := make(map[string]interface{})
+	for k, v := range params {
+		if _, d := splitDirective(k); d != "" {
+			directed[k] = v
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			plain[k] = v
+			continue
+		}
+		pm, dm := splitDirectiveValues(m)
+		if len(dm) > 0 {
+			directed[k] = dm
+		}
+		if len(pm) > 0 || len(dm) == 0 {
+			plain[k] = pm
+		}
+	}
+	return plain, directed
+}
+
+// keepStrings merges the map of strings with the customizing one, the values
+// of the map taking precedence over the plain values of the customizing one
+// but not over its merge directives, which are honoured.
+func keepStrings(r map[string]string, with map[string]string) map[string]string {
+	plain := make(map[string]string)
+	directed := make(map[string]string)
+	for k, v := range with {
+		if _, d := splitDirective(k); d != "" {
+			directed[k] = v
+		} else {
+			plain[k] = v
+		}
+	}
+	return inheritStrings(directed, inheritStrings(r, plain))
+}
+
+// directiveErrors returns the errors of the merge directives used into the parameters
+func directiveErrors(location DescriptorLocation, params map[string]interface{}) ValidationErrors {
+	vErrs := ValidationErrors{}
+	names := make(map[string]string)
+	for _, k := range sortedKeys(params) {
+		v := params[k]
+		name, d := splitDirective(k)
+		l := location.appendPath(k)
+		if other, ok := names[name]; ok {
+			vErrs.addError(fmt.Errorf("conflicting merge of %s: %s and %s", name, other, k), l)
+		}
+		names[name] = k
+		switch d {
+		case DirectiveAppend:
+			if _, ok := v.([]interface{}); !ok {
+				vErrs.addError(fmt.Errorf("invalid merge directive %s on %s: a list is expected", d, name), l)
+			}
+		case DirectiveDelete:
+			if !isDeleted(v) {
+				vErrs.addError(fmt.Errorf("invalid merge directive %s on %s: null or true is expected", d, name), l)
+			}
+			continue
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			vErrs.merge(directiveErrors(l, val))
+		case []interface{}:
+			for i, item := range val {
+				if m, ok := item.(map[string]interface{}); ok {
+					vErrs.merge(stripDirectiveErrors(l.appendIndex(i), m))
+				}
+			}
+		}
+	}
+	return vErrs
+}
+
+// stripDirectiveErrors returns the errors of the merge directives used where
+// no merge occurs, like within the items of a list
+func stripDirectiveErrors(location DescriptorLocation, params map[string]interface{}) ValidationErrors {
+	vErrs := ValidationErrors{}
+	for _, k := range sortedKeys(params) {
+		l := location.appendPath(k)
+		if _, d := splitDirective(k); d != "" {
+			vErrs.addError(fmt.Errorf("merge directive %s not supported within a list", d), l)
+		}
+		if m, ok := params[k].(map[string]interface{}); ok {
+			vErrs.merge(stripDirectiveErrors(l, m))
+		}
+	}
+	return vErrs
+}
+
+// stringDirectiveErrors returns the errors of the merge directives used into
+// environment variables or labels
+func stringDirectiveErrors(location DescriptorLocation, kind string, values map[string]string) ValidationErrors {
+	vErrs := ValidationErrors{}
+	names := make(map[string]string)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name, d := splitDirective(k)
+		l := location.appendPath(k)
+		if other, ok := names[name]; ok {
+			vErrs.addError(fmt.Errorf("conflicting merge of %s: %s and %s", name, other, k), l)
+		}
+		names[name] = k
+		switch d {
+		case DirectiveAppend:
+			vErrs.addError(fmt.Errorf("merge directive %s not supported on %s", d, kind), l)
+		case DirectiveDelete:
+			if v := strings.TrimSpace(values[k]); v != "" && v != "true" {
+				vErrs.addError(fmt.Errorf("invalid merge directive %s on %s: an empty value or true is expected", d, name), l)
+			}
+		}
+	}
+	return vErrs
+}
+
+// directiveErrors returns the errors of the merge directives used into the environment
+func (r *Environment) directiveErrors() ValidationErrors {
+	vErrs := ValidationErrors{}
+	params := func(path string, p Parameters) {
+		vErrs.merge(directiveErrors(r.location.appendPath(path), p))
+	}
+	envVars := func(path string, e EnvVars) {
+		vErrs.merge(stringDirectiveErrors(r.location.appendPath(path), "environment variables", e))
+	}
+	params("vars", r.Vars)
+	params("orchestrator.params", r.Orchestrator.Parameters)
+	envVars("orchestrator.env", r.Orchestrator.EnvVars)
+	for _, name := range r.Providers.names() {
+		p := r.Providers[name]
+		params("providers."+name+".params", p.Parameters)
+		envVars("providers."+name+".env", p.EnvVars)
+	}
+	for _, name := range r.NodeSets.names() {
+		n := r.NodeSets[name]
+		params("nodes."+name+".provider.params", n.Provider.parameters)
+		envVars("nodes."+name+".provider.env", n.Provider.envVars)
+		vErrs.merge(stringDirectiveErrors(r.location.appendPath("nodes."+name+".labels"), "labels", n.Labels))
+	}
+	for _, name := range r.Stacks.names() {
+		s := r.Stacks[name]
+		params("stacks."+name+".params", s.Parameters)
+		envVars("stacks."+name+".env", s.EnvVars)
+	}
+	for _, name := range r.Tasks.names() {
+		t := r.Tasks[name]
+		params("tasks."+name+".params", t.Parameters)
+		envVars("tasks."+name+".env", t.EnvVars)
+	}
+	return vErrs
+}
+
+// applyDirectives applies the merge directives remaining into the elements of
+// the environment which didn't inherit from another one
+func (r *Environment) applyDirectives() {
+	r.Vars = r.Vars.inherit(nil)
+	r.Orchestrator.Parameters = r.Orchestrator.Parameters.inherit(nil)
+	r.Orchestrator.EnvVars = r.Orchestrator.EnvVars.inherit(nil)
+	for name, p := range r.Providers {
+		p.Parameters = p.Parameters.inherit(nil)
+		p.EnvVars = p.EnvVars.inherit(nil)
+		r.Providers[name] = p
+	}
+	for name, n := range r.NodeSets {
+		n.Labels = n.Labels.inherit(nil)
+		r.NodeSets[name] = n
+	}
+	for name, s := range r.Stacks {
+		s.Parameters = s.Parameters.inherit(nil)
+		s.EnvVars = s.EnvVars.inherit(nil)
+		r.Stacks[name] = s
+	}
+	for _, t := range r.Tasks {
+		t.Parameters = t.Parameters.inherit(nil)
+		t.EnvVars = t.EnvVars.inherit(nil)
+	}
+}
+
+// customizingLeaves returns the leaves of a customizing environment, along with
+// the labels deleted with an empty value which are not part of the leaves
+func (r Environment) customizingLeaves() map[string]interface{} {
+	res := r.leaves()
+	for name, n := range r.NodeSets {
+		for k, v := range n.Labels {
+			if _, d := splitDirective(k); d == DirectiveDelete {
+				res["nodes."+name+".labels."+k] = v
+			}
+		}
+	}
+	return res
+}
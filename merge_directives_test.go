@@ -0,0 +1,86 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParametersMergeDirectives(t *testing.T) {
+	parent := CreateParameters(map[string]interface{}{
+		"cidrs":  []interface{}{"10.0.0.0/8"},
+		"tags":   []interface{}{"a"},
+		"debug":  true,
+		"limits": map[string]interface{}{"cpu": 1, "memory": 512},
+		"nested": map[string]interface{}{"key": "value", "other": "value"},
+	})
+	child := CreateParameters(map[string]interface{}{
+		"cidrs!replace":  []interface{}{"192.168.0.0/16"},
+		"tags!append":    []interface{}{1},
+		"debug!delete":   nil,
+		"limits!replace": map[string]interface{}{"cpu": 2, "ignored!delete": true},
+		"nested":         map[string]interface{}{"other!delete": true},
+		"missing!delete": true,
+	})
+	res := child.inherit(parent)
+	assert.Equal(t, Parameters{
+		"cidrs":  []interface{}{"192.168.0.0/16"},
+		"tags":   []interface{}{"a", 1},
+		"limits": map[string]interface{}{"cpu": 2},
+		"nested": map[string]interface{}{"key": "value"},
+	}, res)
+
+	// The directives of the parent are applied on empty content
+	res = Parameters{}.inherit(child)
+	assert.Equal(t, Parameters{
+		"cidrs":  []interface{}{"192.168.0.0/16"},
+		"tags":   []interface{}{1},
+		"limits": map[string]interface{}{"cpu": 2},
+		"nested": map[string]interface{}{},
+	}, res)
+}
+
+func TestStringsMergeDirectives(t *testing.T) {
+	envVars := EnvVars{"KEY!delete": "", "OTHER!replace": "new"}.inherit(map[string]string{"KEY": "value", "OTHER": "old"})
+	assert.Equal(t, EnvVars{"OTHER": "new"}, envVars)
+	labels := Labels{"zone!delete": "true"}.inherit(Labels{"zone": "a", "role": "worker"})
+	assert.Equal(t, Labels{"role": "worker"}, labels)
+}
+
+func TestEnvironmentMergeDirectives(t *testing.T) {
+	assert.False(t, buildEnvironment(t, "./testdata/yaml/directives/main.yaml").directiveErrors().HasErrors())
+	env, err := customizeWith(t, "./testdata/yaml/directives/parent.yaml", "./testdata/yaml/directives/main.yaml")
+	assert.Nil(t, err)
+
+	aws := env.Providers["aws"]
+	assert.Equal(t, Parameters{"region": "eu-west-1", "tags": []interface{}{"ekara", 42}}, aws.Parameters)
+	assert.Equal(t, EnvVars{"AWS_PROFILE": "ekara"}, aws.EnvVars)
+	stack := env.Stacks["stack1"]
+	assert.Equal(t, []interface{}{"192.168.0.0/16"}, stack.Parameters["allowed_cidrs"])
+	assert.Equal(t, []interface{}{80, 443}, stack.Parameters["ports"])
+	assert.Equal(t, map[string]interface{}{"cpu": 2}, stack.Parameters["limits"])
+	// The directives of the customizing node set are honoured despite the labels already defined being kept
+	assert.Equal(t, Labels{"role": "manager"}, env.NodeSets["node1"].Labels)
+	// So are the directives of the customizing vars
+	assert.Equal(t, Parameters{
+		"cidrs":  []interface{}{"b"},
+		"region": "eu-west-1",
+		"db":     map[string]interface{}{"host": "db.local"},
+	}, env.Vars)
+}
+
+func TestInvalidMergeDirectives(t *testing.T) {
+	env := buildEnvironment(t, "./testdata/yaml/directives/invalid.yaml")
+	vErrs := env.Validate()
+	assert.True(t, vErrs.contains(Error, "invalid merge directive !delete on debug: null or true is expected", "providers.aws.params.debug!delete"))
+	assert.True(t, vErrs.contains(Error, "invalid merge directive !append on region: a list is expected", "providers.aws.params.region!append"))
+	assert.True(t, vErrs.contains(Error, "conflicting merge of tags: tags and tags!replace", "providers.aws.params.tags!replace"))
+	assert.True(t, vErrs.contains(Error, "merge directive !replace not supported within a list", "providers.aws.params.list[0].key!replace"))
+	assert.True(t, vErrs.contains(Error, "merge directive !append not supported on environment variables", "providers.aws.env.PATH!append"))
+	assert.True(t, vErrs.contains(Error, "invalid merge directive !delete on zone: an empty value or true is expected", "nodes.node1.labels.zone!delete"))
+
+	errs := vErrs.locate("conflicting merge of tags: tags and tags!replace")
+	if assert.Equal(t, 1, len(errs)) {
+		assert.Equal(t, 14, errs[0].Location.Line)
+	}
+}
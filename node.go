@@ -28,6 +28,8 @@ type (
 		Hooks NodeHook `yaml:",omitempty"`
 		// The labels associated with the nodeset
 		Labels Labels `yaml:",omitempty,flow"`
+		// Indicates if the inherited node set must be removed
+		remove bool
 	}
 
 	//NodeSets represents all the node sets of the environment
@@ -67,7 +69,9 @@ func (r *NodeSet) customize(with NodeSet) error {
 	if with.Instances > 0 {
 		r.Instances = with.Instances
 	}
-	r.Labels = r.Labels.inherit(with.Labels)
+	// The labels already defined are kept, unless changed by the merge
+	// directives of the customizing node set
+	r.Labels = keepStrings(r.Labels, with.Labels)
 	return nil
 }
 
@@ -124,7 +128,8 @@ func buildNode(name string, env *Environment, location DescriptorLocation, yN ya
 		Hooks: NodeHook{
 			Create: pHook,
 		},
-		Labels: yN.Labels}, nil
+		Labels: yN.Labels,
+		remove: yN.Remove}, nil
 }
 
 func (r NodeSets) customize(env *Environment, with NodeSets) (NodeSets, error) {
@@ -134,6 +139,13 @@ func (r NodeSets) customize(env *Environment, with NodeSets) (NodeSets, error) {
 	}
 
 	for id, n := range with {
+		if n.remove {
+			if _, ok := res[id]; ok {
+				delete(res, id)
+				env.markRemoved("nodes", id)
+			}
+			continue
+		}
 		if nodeSet, ok := res[id]; ok {
 			nm := &nodeSet
 			if err := nm.customize(n); err != nil {
@@ -147,3 +159,8 @@ func (r NodeSets) customize(env *Environment, with NodeSets) (NodeSets, error) {
 	}
 	return res, nil
 }
+
+// names returns the names of the node sets in alphabetical order
+func (r NodeSets) names() []string {
+	return sortedKeys(r)
+}
@@ -5,6 +5,8 @@ type (
 	Orchestrator struct {
 		// The component containing the orchestrator
 		cRef componentRef
+		// The location of the orchestrator into the descriptor
+		location DescriptorLocation
 		// The orchestrator parameters
 		Parameters Parameters `yaml:",omitempty"`
 		// The orchestrator environment variables
@@ -15,6 +17,7 @@ type (
 func createOrchestrator(env *Environment, location DescriptorLocation, yamlEnv *yamlEnvironment) (Orchestrator, error) {
 	yamlO := yamlEnv.Orchestrator
 	o := Orchestrator{
+		location:   location,
 		cRef:       createComponentRef(env, location.appendPath("component"), yamlO.Component, true),
 		Parameters: CreateParameters(yamlO.Params),
 		EnvVars:    createEnvVars(yamlO.Env),
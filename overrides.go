@@ -0,0 +1,458 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// overrideSegment represents a segment of the path of an override
+	overrideSegment struct {
+		// key is the map key of the segment
+		key string
+		// indexes are the list indexes following the key, like in "key[0][1]"
+		indexes []int
+	}
+)
+
+// ParseSetValues parses command line style overrides, like
+// "providers.aws.params.region=eu-west-1", into Parameters.
+//
+// The path is made of keys separated by dots, a key can be followed by list
+// indexes like in "vars.zones[1]=eu-west-1b". The dots, brackets, equal signs
+// and backslashes belonging to a key must be escaped with a backslash.
+//
+// The values are typed like the yaml scalars: "3" is an int, "true" a bool and
+// "'3'" a string. The yaml flow lists and maps, like "[a, b]", are also accepted.
+func ParseSetValues(assignments ...string) (Parameters, error) {
+	return parseAssignments(assignments, func(v string) (interface{}, error) {
+		var res interface{}
+		if err := yaml.Unmarshal([]byte(v), &res); err != nil {
+			return nil, err
+		}
+		return res, nil
+	})
+}
+
+// ParseSetStringValues parses command line style overrides like ParseSetValues,
+// all the values being kept as strings.
+func ParseSetStringValues(assignments ...string) (Parameters, error) {
+	return parseAssignments(assignments, func(v string) (interface{}, error) {
+		return v, nil
+	})
+}
+
+// ParseSetFileValues parses command line style overrides, like
+// "vars.cert=./cert.pem", where the values are read from the given files.
+//
+// See ParseSetValues for the syntax of the paths.
+func ParseSetFileValues(assignments ...string) (Parameters, error) {
+	return parseAssignments(assignments, func(v string) (interface{}, error) {
+		b, err := ioutil.ReadFile(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	})
+}
+
+func parseAssignments(assignments []string, value func(v string) (interface{}, error)) (Parameters, error) {
+	res := make(map[interface{}]interface{})
+	for _, a := range assignments {
+		segments, raw, err := parseOverridePath(a)
+		if err != nil {
+			return nil, err
+		}
+		v, err := value(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in %s: %s", a, err.Error())
+		}
+		if err := setOverride(res, segments, v); err != nil {
+			return nil, fmt.Errorf("invalid assignment %s: %s", a, err.Error())
+		}
+	}
+	return CreateParameters(normalizeParameter(res).(map[string]interface{})), nil
+}
+
+// parseOverridePath splits an assignment into the segments of its path and its raw value
+func parseOverridePath(a string) ([]overrideSegment, string, error) {
+	segments := make([]overrideSegment, 0)
+	current := overrideSegment{}
+	key := strings.Builder{}
+	closed := false
+	for i := 0; i < len(a); i++ {
+		c := a[i]
+		switch {
+		case c == '\\' && i+1 < len(a):
+			if closed {
+				return nil, "", fmt.Errorf("invalid path in %s: unexpected character after index", a)
+			}
+			i++
+			key.WriteByte(a[i])
+		case c == '.' || c == '=':
+			current.key = key.String()
+			if current.key == "" {
+				return nil, "", fmt.Errorf("invalid path in %s: empty key", a)
+			}
+			segments = append(segments, current)
+			if c == '=' {
+				return segments, a[i+1:], nil
+			}
+			current = overrideSegment{}
+			key.Reset()
+			closed = false
+		case c == '[':
+			end := strings.IndexByte(a[i:], ']')
+			if end < 0 {
+				return nil, "", fmt.Errorf("invalid path in %s: missing ]", a)
+			}
+			idx, err := strconv.Atoi(a[i+1 : i+end])
+			if err != nil || idx < 0 {
+				return nil, "", fmt.Errorf("invalid path in %s: invalid index %s", a, a[i+1:i+end])
+			}
+			current.indexes = append(current.indexes, idx)
+			i += end
+			closed = true
+		default:
+			if closed {
+				return nil, "", fmt.Errorf("invalid path in %s: unexpected character after index", a)
+			}
+			key.WriteByte(c)
+		}
+	}
+	return nil, "", fmt.Errorf("invalid assignment %s: missing =", a)
+}
+
+// setOverride sets the value at the location of the segments into the tree
+func setOverride(tree map[interface{}]interface{}, segments []overrideSegment, v interface{}) error {
+	s := segments[0]
+	if len(s.indexes) == 0 {
+		if len(segments) == 1 {
+			tree[s.key] = v
+			return nil
+		}
+		sub, ok := tree[s.key].(map[interface{}]interface{})
+		if !ok {
+			if _, exists := tree[s.key]; exists {
+				return fmt.Errorf("%s is not a map", s.key)
+			}
+			sub = make(map[interface{}]interface{})
+			tree[s.key] = sub
+		}
+		return setOverride(sub, segments[1:], v)
+	}
+	if _, exists := tree[s.key]; exists {
+		if _, ok := tree[s.key].([]interface{}); !ok {
+			return fmt.Errorf("%s is not a list", s.key)
+		}
+	}
+	list, _ := tree[s.key].([]interface{})
+	res, err := setOverrideItem(list, s.indexes, segments[1:], v)
+	if err != nil {
+		return fmt.Errorf("%s%s", s.key, err.Error())
+	}
+	tree[s.key] = res
+	return nil
+}
+
+func setOverrideItem(list []interface{}, indexes []int, segments []overrideSegment, v interface{}) ([]interface{}, error) {
+	i := indexes[0]
+	for len(list) <= i {
+		list = append(list, nil)
+	}
+	switch {
+	case len(indexes) > 1:
+		sub, ok := list[i].([]interface{})
+		if !ok && list[i] != nil {
+			return nil, fmt.Errorf("[%d] is not a list", i)
+		}
+		res, err := setOverrideItem(sub, indexes[1:], segments, v)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]%s", i, err.Error())
+		}
+		list[i] = res
+	case len(segments) > 0:
+		sub, ok := list[i].(map[interface{}]interface{})
+		if !ok {
+			if list[i] != nil {
+				return nil, fmt.Errorf("[%d] is not a map", i)
+			}
+			sub = make(map[interface{}]interface{})
+			list[i] = sub
+		}
+		if err := setOverride(sub, segments, v); err != nil {
+			return nil, fmt.Errorf("[%d].%s", i, err.Error())
+		}
+	default:
+		list[i] = v
+	}
+	return list, nil
+}
+
+// override returns the value overridden by the given one.
+//
+// The maps are merged deeply and the items of the lists are overridden
+// individually, the nil items of the overriding list leaving the original
+// items unchanged.
+func override(v interface{}, with interface{}) interface{} {
+	switch w := with.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{})
+		if o, ok := v.(map[string]interface{}); ok {
+			for k, e := range o {
+				res[k] = e
+			}
+		}
+		for k, e := range w {
+			res[k] = override(res[k], e)
+		}
+		return res
+	case []interface{}:
+		o, _ := v.([]interface{})
+		res := append([]interface{}{}, o...)
+		for i, e := range w {
+			if i >= len(res) {
+				res = append(res, e)
+			} else if e != nil {
+				res[i] = override(res[i], e)
+			}
+		}
+		return res
+	}
+	return with
+}
+
+// overrideParameters returns the parameters overridden by the given values
+func overrideParameters(p Parameters, with interface{}) (Parameters, error) {
+	w, ok := with.(map[string]interface{})
+	if !ok {
+		return p, errors.New("a map of parameters is expected")
+	}
+	res := CloneParameters(p)
+	for k, v := range w {
+		res[k] = override(res[k], v)
+	}
+	return res, nil
+}
+
+// overrideEnvVars returns the environment variables overridden by the given values
+func overrideEnvVars(e EnvVars, with interface{}) (EnvVars, error) {
+	w, ok := with.(map[string]interface{})
+	if !ok {
+		return e, errors.New("a map of environment variables is expected")
+	}
+	res := createEnvVars(e)
+	for k, v := range w {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return e, fmt.Errorf("%s: a scalar value is expected", k)
+		}
+		res[k] = fmt.Sprintf("%v", v)
+	}
+	return res, nil
+}
+
+// overrideProxy returns the proxy overridden by the given values
+func overrideProxy(p Proxy, with interface{}) (Proxy, error) {
+	w, ok := with.(map[string]interface{})
+	if !ok {
+		return p, errors.New("a map is expected")
+	}
+	for k, v := range w {
+		s := fmt.Sprintf("%v", v)
+		switch k {
+		case "http_proxy":
+			p.Http = s
+		case "https_proxy":
+			p.Https = s
+		case "no_proxy":
+			p.NoProxy = s
+		default:
+			return p, fmt.Errorf("unknown proxy setting: %s", k)
+		}
+	}
+	return p, nil
+}
+
+// ApplyOverrides applies the overrides, as parsed by ParseSetValues, on the
+// environment.
+//
+// The overrides can target the vars, the orchestrator, a provider, a node
+// set, a stack or a task:
+//  vars.<path>
+//  orchestrator.params.<path>, orchestrator.env.<name>
+//  providers.<name>.params.<path>, providers.<name>.env.<name>, providers.<name>.proxy.<setting>
+//  nodes.<name>.instances, nodes.<name>.labels.<name>
+//  nodes.<name>.provider.params.<path>, nodes.<name>.provider.env.<name>, nodes.<name>.provider.proxy.<setting>
+//  stacks.<name>.params.<path>, stacks.<name>.env.<name>
+//  tasks.<name>.params.<path>, tasks.<name>.env.<name>
+//
+// The targeted providers, node sets, stacks and tasks must exist into the
+// environment. An error is reported for each invalid override, the valid ones
+// being applied.
+func (r *Environment) ApplyOverrides(overrides Parameters) ValidationErrors {
+	vErrs := ValidationErrors{}
+	overrides = CreateParameters(overrides)
+	location := DescriptorLocation{Descriptor: "overrides"}
+	fail := func(path string, err error) {
+		vErrs.addError(err, location.appendPath(path))
+	}
+	for _, k := range sortedKeys(overrides) {
+		v := overrides[k]
+		switch k {
+		case "vars":
+			p, err := overrideParameters(r.Vars, v)
+			if err != nil {
+				fail(k, err)
+				continue
+			}
+			r.Vars = p
+		case "orchestrator":
+			r.applyOverrides(k, v, fail, func(key string, val interface{}) error {
+				var err error
+				switch key {
+				case "params":
+					r.Orchestrator.Parameters, err = overrideParameters(r.Orchestrator.Parameters, val)
+				case "env":
+					r.Orchestrator.EnvVars, err = overrideEnvVars(r.Orchestrator.EnvVars, val)
+				default:
+					err = fmt.Errorf("unsupported override: %s", key)
+				}
+				return err
+			})
+		case "providers", "nodes", "stacks", "tasks":
+			elements, ok := v.(map[string]interface{})
+			if !ok {
+				fail(k, errors.New("a map is expected"))
+				continue
+			}
+			for _, name := range sortedKeys(elements) {
+				path := k + "." + name
+				if !r.hasElement(k, name) {
+					fail(path, fmt.Errorf("unknown %s: %s", strings.TrimSuffix(k, "s"), name))
+					continue
+				}
+				r.applyOverrides(path, elements[name], fail, func(key string, val interface{}) error {
+					return r.overrideElement(k, name, key, val)
+				})
+			}
+		default:
+			fail(k, fmt.Errorf("unsupported override: %s", k))
+		}
+	}
+	return vErrs
+}
+
+// applyOverrides applies each entry of the overrides located by the path
+func (r *Environment) applyOverrides(path string, v interface{}, fail func(string, error), apply func(key string, val interface{}) error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		fail(path, errors.New("a map is expected"))
+		return
+	}
+	for _, key := range sortedKeys(m) {
+		if err := apply(key, m[key]); err != nil {
+			fail(path+"."+key, err)
+		}
+	}
+}
+
+// hasElement returns true if the environment contains the element of the given kind
+func (r *Environment) hasElement(kind string, name string) bool {
+	ok := false
+	switch kind {
+	case "providers":
+		_, ok = r.Providers[name]
+	case "nodes":
+		_, ok = r.NodeSets[name]
+	case "stacks":
+		_, ok = r.Stacks[name]
+	case "tasks":
+		_, ok = r.Tasks[name]
+	}
+	return ok
+}
+
+// overrideElement applies the override of the given key on the element of the given kind
+func (r *Environment) overrideElement(kind string, name string, key string, v interface{}) error {
+	var err error
+	switch kind {
+	case "providers":
+		p := r.Providers[name]
+		switch key {
+		case "params":
+			p.Parameters, err = overrideParameters(p.Parameters, v)
+		case "env":
+			p.EnvVars, err = overrideEnvVars(p.EnvVars, v)
+		case "proxy":
+			p.Proxy, err = overrideProxy(p.Proxy, v)
+		default:
+			return fmt.Errorf("unsupported override: %s", key)
+		}
+		r.Providers[name] = p
+	case "nodes":
+		n := r.NodeSets[name]
+		switch key {
+		case "instances":
+			i, ok := v.(int)
+			if !ok {
+				return errors.New("an int is expected")
+			}
+			n.Instances = i
+		case "labels":
+			var labels EnvVars
+			labels, err = overrideEnvVars(EnvVars(n.Labels), v)
+			n.Labels = Labels(labels)
+		case "provider":
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return errors.New("a map is expected")
+			}
+			for _, k := range sortedKeys(m) {
+				switch k {
+				case "params":
+					n.Provider.parameters, err = overrideParameters(n.Provider.parameters, m[k])
+				case "env":
+					n.Provider.envVars, err = overrideEnvVars(n.Provider.envVars, m[k])
+				case "proxy":
+					n.Provider.proxy, err = overrideProxy(n.Provider.proxy, m[k])
+				default:
+					err = fmt.Errorf("unsupported override: provider.%s", k)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unsupported override: %s", key)
+		}
+		r.NodeSets[name] = n
+	case "stacks":
+		s := r.Stacks[name]
+		switch key {
+		case "params":
+			s.Parameters, err = overrideParameters(s.Parameters, v)
+		case "env":
+			s.EnvVars, err = overrideEnvVars(s.EnvVars, v)
+		default:
+			return fmt.Errorf("unsupported override: %s", key)
+		}
+		r.Stacks[name] = s
+	case "tasks":
+		t := r.Tasks[name]
+		switch key {
+		case "params":
+			t.Parameters, err = overrideParameters(t.Parameters, v)
+		case "env":
+			t.EnvVars, err = overrideEnvVars(t.EnvVars, v)
+		default:
+			return fmt.Errorf("unsupported override: %s", key)
+		}
+	}
+	return err
+}
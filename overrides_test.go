@@ -0,0 +1,117 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSetValues(t *testing.T) {
+	p, err := ParseSetValues(
+		"providers.aws.params.region=eu-west-1",
+		"vars.replicas=3",
+		"vars.debug=true",
+		"vars.version='3'",
+		"vars.zones[1]=eu-west-1b",
+		"vars.tags=[a, b]",
+		`vars.a\.b\=c=d=e`,
+		"vars.users[0].name=admin",
+	)
+	if assert.Nil(t, err) {
+		vars := p["vars"].(map[string]interface{})
+		assert.Equal(t, 3, vars["replicas"])
+		assert.Equal(t, true, vars["debug"])
+		assert.Equal(t, "3", vars["version"])
+		assert.Equal(t, []interface{}{nil, "eu-west-1b"}, vars["zones"])
+		assert.Equal(t, []interface{}{"a", "b"}, vars["tags"])
+		assert.Equal(t, "d=e", vars["a.b=c"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "admin"}}, vars["users"])
+		aws := p["providers"].(map[string]interface{})["aws"].(map[string]interface{})
+		assert.Equal(t, "eu-west-1", aws["params"].(map[string]interface{})["region"])
+	}
+}
+
+func TestParseSetStringValues(t *testing.T) {
+	p, err := ParseSetStringValues("vars.replicas=3")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "3", p["vars"].(map[string]interface{})["replicas"])
+	}
+}
+
+func TestParseSetValuesInvalid(t *testing.T) {
+	for _, a := range []string{"vars.key", "vars..key=v", "vars.key[a]=v", "vars.key[0=v", "vars.key[0]x=v"} {
+		_, err := ParseSetValues(a)
+		assert.NotNil(t, err, a)
+	}
+	_, err := ParseSetValues("vars.key=v", "vars.key.sub=v")
+	assert.NotNil(t, err)
+}
+
+func TestParseSetFileValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ekara_set_file")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cert.pem")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("certificate content"), 0644))
+
+	p, err := ParseSetFileValues("vars.cert=" + file)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "certificate content", p["vars"].(map[string]interface{})["cert"])
+	}
+	_, err = ParseSetFileValues("vars.cert=" + filepath.Join(dir, "missing.pem"))
+	assert.NotNil(t, err)
+}
+
+func TestApplyOverrides(t *testing.T) {
+	env := buildEnvironment(t, "./testdata/yaml/complete.yaml")
+	p, err := ParseSetValues(
+		"vars.global_var_key1=overridden",
+		"vars.new.key=added",
+		"orchestrator.env.new_env=1",
+		"providers.aws.params.aws_param_key1=overridden",
+		"providers.aws.proxy.http_proxy=http://proxy:3128",
+		"nodes.node1.instances=3",
+		"nodes.node1.provider.params.provider_node1_param_key1=overridden",
+		"stacks.stack1.params.replicas=2",
+		"tasks.task1.env.tasks_task1_env_key1=overridden",
+	)
+	assert.Nil(t, err)
+	vErrs := env.ApplyOverrides(p)
+	assert.False(t, vErrs.HasErrors())
+
+	assert.Equal(t, "overridden", env.Vars["global_var_key1"])
+	assert.Equal(t, "global_var_val2", env.Vars["global_var_key2"])
+	assert.Equal(t, map[string]interface{}{"key": "added"}, env.Vars["new"])
+	assert.Equal(t, "1", env.Orchestrator.EnvVars["new_env"])
+	assert.Equal(t, "overridden", env.Providers["aws"].Parameters["aws_param_key1"])
+	assert.Equal(t, "aws_param_key2_value", env.Providers["aws"].Parameters["aws_param_key2"])
+	assert.Equal(t, "http://proxy:3128", env.Providers["aws"].Proxy.Http)
+	assert.Equal(t, "aws_https_proxy", env.Providers["aws"].Proxy.Https)
+	assert.Equal(t, 3, env.NodeSets["node1"].Instances)
+	assert.Equal(t, "overridden", env.NodeSets["node1"].Provider.parameters["provider_node1_param_key1"])
+	assert.Equal(t, 2, env.Stacks["stack1"].Parameters["replicas"])
+	assert.Equal(t, "overridden", env.Tasks["task1"].EnvVars["tasks_task1_env_key1"])
+}
+
+func TestApplyOverridesInvalid(t *testing.T) {
+	env := buildEnvironment(t, "./testdata/yaml/complete.yaml")
+	p, err := ParseSetValues(
+		"providers.missing.params.key=v",
+		"nodes.node1.instances=many",
+		"stacks.stack1.playbook=other",
+		"unknown.key=v",
+		"vars.global_var_key1=valid",
+	)
+	assert.Nil(t, err)
+	vErrs := env.ApplyOverrides(p)
+	assert.Equal(t, 4, len(vErrs.Errors))
+	assert.True(t, vErrs.contains(Error, "unknown provider: missing", "providers.missing"))
+	assert.True(t, vErrs.contains(Error, "an int is expected", "nodes.node1.instances"))
+	assert.True(t, vErrs.contains(Error, "unsupported override: playbook", "stacks.stack1.playbook"))
+	assert.True(t, vErrs.contains(Error, "unsupported override: unknown", "unknown"))
+	// The valid overrides are applied
+	assert.Equal(t, "valid", env.Vars["global_var_key1"])
+}
@@ -1,11 +1,13 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"reflect"
 
-	jsoniter "github.com/json-iterator/go"
 	"gopkg.in/yaml.v2"
 )
 
@@ -14,30 +16,25 @@ type ParametersAware interface {
 	ParamsInfo() Parameters
 }
 
-// Parameters represents the parameters coming from a descriptor
+// Parameters represents the parameters coming from a descriptor.
+//
+// The parameters always hold a normalized tree, compatible with JSON: the
+// maps are keyed by strings, the lists are []interface{} and the scalars
+// are strings, booleans, ints, float64 or nil.
 type Parameters map[string]interface{}
 
 // CreateParameters builds Parameters from the specified map
 func CreateParameters(src map[string]interface{}) Parameters {
 	dst := make(map[string]interface{})
 	for k, v := range src {
-		dst[k] = v
+		dst[k] = normalizeParameter(v)
 	}
 	return dst
 }
 
 // CloneParameters deep-copy the entire parameters
 func CloneParameters(other Parameters) Parameters {
-	cp := make(map[string]interface{})
-	for k, v := range other {
-		vm, ok := v.(map[string]interface{})
-		if ok {
-			cp[k] = CloneParameters(vm)
-		} else {
-			cp[k] = v
-		}
-	}
-	return cp
+	return CreateParameters(other)
 }
 
 // ParseParameters parses a yaml file into a Parameters
@@ -55,49 +52,173 @@ func ParseParameters(path string) (Parameters, error) {
 	return r, nil
 }
 
-// TODO: terrible! change this
-func (r Parameters) inherit(parent Parameters) Parameters {
-	parentG := make(map[interface{}]interface{})
-	for k, v := range parent {
-		parentG[k] = v
+// UnmarshalYAML unmarshals and normalizes the parameters
+func (r *Parameters) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	m := make(map[interface{}]interface{})
+	if err := unmarshal(&m); err != nil {
+		return err
 	}
-	rG := make(map[interface{}]interface{})
-	for k, v := range r {
-		rG[k] = v
+	*r = CreateParameters(normalizeParameter(m).(map[string]interface{}))
+	return nil
+}
+
+// UnmarshalJSON unmarshals and normalizes the parameters
+//
+// The integer numbers are unmarshalled as ints, the other ones as float64.
+func (r *Parameters) UnmarshalJSON(b []byte) error {
+	m := make(map[string]interface{})
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil {
+		return err
+	}
+	*r = CreateParameters(m)
+	return nil
+}
+
+// normalizeParameter returns the given value normalized as a JSON compatible tree.
+//
+// The values which cannot be represented into such a tree are returned untouched.
+func normalizeParameter(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, string, bool, int:
+		return val
+	case float64:
+		return normalizeFloat(val)
+	case float32:
+		return normalizeFloat(float64(val))
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return normalizeInt(i)
+		}
+		f, _ := val.Float64()
+		return normalizeFloat(f)
+	case Parameters:
+		return normalizeParameter(map[string]interface{}(val))
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			res[k] = normalizeParameter(e)
+		}
+		return res
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			res[fmt.Sprintf("%v", k)] = normalizeParameter(e)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, e := range val {
+			res[i] = normalizeParameter(e)
+		}
+		return res
 	}
-	dst := make(map[interface{}]interface{})
-	merge(dst, parentG)
-	merge(dst, rG)
-	ret := make(map[string]interface{})
-	for k, v := range dst {
-		ret[fmt.Sprintf("%v", k)] = v
+	vv := reflect.ValueOf(v)
+	switch vv.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return normalizeInt(vv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if vv.Uint() <= math.MaxInt64 {
+			return normalizeInt(int64(vv.Uint()))
+		}
+		return float64(vv.Uint())
+	case reflect.String:
+		return vv.String()
+	case reflect.Bool:
+		return vv.Bool()
+	case reflect.Slice, reflect.Array:
+		res := make([]interface{}, vv.Len())
+		for i := 0; i < vv.Len(); i++ {
+			res[i] = normalizeParameter(vv.Index(i).Interface())
+		}
+		return res
+	case reflect.Map:
+		res := make(map[string]interface{}, vv.Len())
+		for _, k := range vv.MapKeys() {
+			res[fmt.Sprintf("%v", k.Interface())] = normalizeParameter(vv.MapIndex(k).Interface())
+		}
+		return res
+	case reflect.Ptr, reflect.Interface:
+		if vv.IsNil() {
+			return nil
+		}
+		return normalizeParameter(vv.Elem().Interface())
+	case reflect.Float32, reflect.Float64:
+		return normalizeFloat(vv.Float())
 	}
-	return ret
+	// The values which have no JSON compatible representation, like the
+	// structs, the functions or the channels, are kept as is
+	return v
 }
 
-func merge(dst map[interface{}]interface{}, src map[interface{}]interface{}) {
-	for k, v := range src {
-		vv := reflect.ValueOf(v)
-		if vv.Kind() == reflect.Map {
-			// The value is a map so we try to go deeper if they have the same key type
-			// Otherwise we overwrite the destination map with the source one
-			vd := reflect.ValueOf(dst[k])
-			if vd.Kind() != reflect.Map || vd.Type().Key() != vv.Type().Key() {
-				dst[k] = make(map[interface{}]interface{})
+// normalizeInt returns the int64 as an int if it fits, as a float64 otherwise
+func normalizeInt(i int64) interface{} {
+	if int64(int(i)) == i {
+		return int(i)
+	}
+	return float64(i)
+}
+
+// normalizeFloat returns the float64 as an int if it holds an integer value,
+// as JSON doesn't distinguish them
+func normalizeFloat(f float64) interface{} {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int(f)
+	}
+	return f
+}
+
+// inherit returns the parameters merged with the parent ones.
+//
+// The values of the receiver take precedence, the maps are merged deeply and
+// the lists are concatenated.
+//
+// The merge directives suffixing the keys, like "cidrs!replace", are honoured
+// and removed from the result.
+func (r Parameters) inherit(parent Parameters) Parameters {
+	dst := make(map[string]interface{})
+	merge(dst, CreateParameters(parent))
+	merge(dst, CreateParameters(r))
+	return dst
+}
+
+func merge(dst map[string]interface{}, src map[string]interface{}) {
+	for _, k := range directiveKeys(sortedKeys(src)) {
+		v := src[k]
+		name, d := splitDirective(k)
+		switch d {
+		case DirectiveDelete:
+			delete(dst, name)
+			continue
+		case DirectiveReplace:
+			// The directives of the replacing value are applied on empty content
+			if m, ok := v.(map[string]interface{}); ok {
+				rm := make(map[string]interface{})
+				merge(rm, m)
+				v = rm
 			}
-			merge(dst[k].(map[interface{}]interface{}), v.(map[interface{}]interface{}))
-		} else if vv.Kind() == reflect.Slice {
-			// The value is a slice so we try to concatenate if they have the same element type
-			// Otherwise we overwrite the destination slice with the source one
-			vd := reflect.ValueOf(dst[k])
-			if vd.Kind() != reflect.Slice || vd.Type().Elem() != vv.Type().Elem() {
-				dst[k] = reflect.MakeSlice(reflect.SliceOf(vv.Type().Elem()), 0, vv.Len()).Interface()
-				vd = reflect.ValueOf(dst[k])
+			dst[name] = v
+			continue
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			// The value is a map so we try to go deeper
+			// Otherwise we overwrite the destination value with the source map
+			dm, ok := dst[name].(map[string]interface{})
+			if !ok {
+				dm = make(map[string]interface{})
 			}
-			dst[k] = reflect.AppendSlice(vd, vv).Interface()
-		} else {
+			merge(dm, val)
+			dst[name] = dm
+		case []interface{}:
+			// The value is a list so we concatenate it to the destination list,
+			// a "!append" directive being the explicit form of this default
+			dl, _ := dst[name].([]interface{})
+			dst[name] = append(append([]interface{}{}, dl...), val...)
+		default:
 			if v != nil {
-				dst[k] = v
+				dst[name] = v
 			}
 		}
 	}
@@ -200,8 +321,9 @@ func readSlice(cKv chan string, exit chan bool, i int, ident int, src []interfac
 	}
 }
 
+//Json returns the content as JSON, once normalized like the Parameters
 func Json(v interface{}) string {
-	strB, err := jsoniter.Marshal(v)
+	strB, err := json.Marshal(normalizeParameter(v))
 	if err != nil {
 		return err.Error()
 	}
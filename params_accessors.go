@@ -0,0 +1,215 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Has returns true if the parameters contain a value at the given dotted path,
+// like "db.credentials.user".
+//
+// The maps within the parameters can be keyed by strings or, as produced by
+// yaml.v2, by interfaces. The items of a list can be reached using their index,
+// like "zones.0".
+func (r Parameters) Has(path string) bool {
+	_, ok := r.lookup(path)
+	return ok
+}
+
+// String returns the string located by the given dotted path.
+//
+// Numbers and booleans are converted into strings, an error is returned if the
+// path doesn't exist or if it locates a map or a list.
+func (r Parameters) String(path string) (string, error) {
+	v, err := r.get(path)
+	if err != nil {
+		return "", err
+	}
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case int, int64, float64, bool:
+		return fmt.Sprintf("%v", val), nil
+	}
+	return "", paramTypeError(path, "string", v)
+}
+
+// Int returns the int located by the given dotted path.
+//
+// Strings, like the templated values, are parsed. An error is returned if the
+// path doesn't exist or if the value is not an integer.
+func (r Parameters) Int(path string) (int, error) {
+	v, err := r.get(path)
+	if err != nil {
+		return 0, err
+	}
+	switch val := v.(type) {
+	case int:
+		return val, nil
+	case int64:
+		return int(val), nil
+	case float64:
+		if val == float64(int(val)) {
+			return int(val), nil
+		}
+	case string:
+		if i, err := strconv.Atoi(strings.TrimSpace(val)); err == nil {
+			return i, nil
+		}
+	}
+	return 0, paramTypeError(path, "int", v)
+}
+
+// Bool returns the bool located by the given dotted path.
+//
+// Strings, like the templated values, are parsed. An error is returned if the
+// path doesn't exist or if the value is not a boolean.
+func (r Parameters) Bool(path string) (bool, error) {
+	v, err := r.get(path)
+	if err != nil {
+		return false, err
+	}
+	switch val := v.(type) {
+	case bool:
+		return val, nil
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(val)); err == nil {
+			return b, nil
+		}
+	}
+	return false, paramTypeError(path, "bool", v)
+}
+
+// Duration returns the duration located by the given dotted path.
+//
+// Strings are parsed like "1h30m" and integers are considered as a number of
+// seconds. An error is returned if the path doesn't exist or if the value is not
+// a duration.
+func (r Parameters) Duration(path string) (time.Duration, error) {
+	v, err := r.get(path)
+	if err != nil {
+		return 0, err
+	}
+	switch val := v.(type) {
+	case time.Duration:
+		return val, nil
+	case int:
+		return time.Duration(val) * time.Second, nil
+	case int64:
+		return time.Duration(val) * time.Second, nil
+	case string:
+		if d, err := time.ParseDuration(strings.TrimSpace(val)); err == nil {
+			return d, nil
+		}
+	}
+	return 0, paramTypeError(path, "duration", v)
+}
+
+// StringSlice returns the list of strings located by the given dotted path.
+//
+// The numbers and booleans of the list are converted into strings. An error is
+// returned if the path doesn't exist or if the value is not a list of scalars.
+func (r Parameters) StringSlice(path string) ([]string, error) {
+	v, err := r.get(path)
+	if err != nil {
+		return nil, err
+	}
+	switch val := v.(type) {
+	case []string:
+		return append([]string{}, val...), nil
+	case []interface{}:
+		res := make([]string, 0, len(val))
+		for i, item := range val {
+			switch s := item.(type) {
+			case string:
+				res = append(res, s)
+			case int, int64, float64, bool:
+				res = append(res, fmt.Sprintf("%v", s))
+			default:
+				return nil, paramTypeError(path+"."+strconv.Itoa(i), "string", item)
+			}
+		}
+		return res, nil
+	}
+	return nil, paramTypeError(path, "list of strings", v)
+}
+
+// Map returns, as Parameters, the map located by the given dotted path.
+//
+// An error is returned if the path doesn't exist or if the value is not a map.
+func (r Parameters) Map(path string) (Parameters, error) {
+	v, err := r.get(path)
+	if err != nil {
+		return nil, err
+	}
+	switch val := v.(type) {
+	case Parameters:
+		return val, nil
+	case map[string]interface{}:
+		return Parameters(val), nil
+	case map[interface{}]interface{}:
+		res := make(Parameters, len(val))
+		for k, e := range val {
+			res[fmt.Sprintf("%v", k)] = e
+		}
+		return res, nil
+	}
+	return nil, paramTypeError(path, "map", v)
+}
+
+// get returns the value located by the given dotted path or an error if the path doesn't exist
+func (r Parameters) get(path string) (interface{}, error) {
+	v, ok := r.lookup(path)
+	if !ok {
+		return nil, fmt.Errorf("parameter %s not found", path)
+	}
+	return v, nil
+}
+
+// lookup returns the value located by the given dotted path
+func (r Parameters) lookup(path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+	var current interface{} = map[string]interface{}(r)
+	for _, s := range strings.Split(path, ".") {
+		var ok bool
+		switch c := current.(type) {
+		case Parameters:
+			current, ok = c[s]
+		case map[string]interface{}:
+			current, ok = c[s]
+		case map[interface{}]interface{}:
+			current, ok = c[s]
+			if !ok {
+				// The yaml keys can be numbers or booleans
+				for k, v := range c {
+					if fmt.Sprintf("%v", k) == s {
+						current, ok = v, true
+						break
+					}
+				}
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(s)
+			if ok = err == nil && i >= 0 && i < len(c); ok {
+				current = c[i]
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// paramTypeError returns the error produced when a parameter has not the expected type
+func paramTypeError(path string, expected string, v interface{}) error {
+	got := "null"
+	if v != nil {
+		got = inputValueType(v)
+	}
+	return fmt.Errorf("invalid parameter %s: %s expected, got %s", path, expected, got)
+}
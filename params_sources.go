@@ -0,0 +1,237 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type (
+	// ParametersSource represents a source of parameters, like a file or the
+	// command line overrides
+	ParametersSource struct {
+		// Name identifies the source, for example the path of its file
+		Name string
+		// Parameters are the parameters provided by the source
+		Parameters Parameters
+	}
+
+	// LayeredParameters represents the result of the merge of several sources of parameters
+	LayeredParameters struct {
+		// Parameters are the merged parameters
+		Parameters Parameters
+		// Sources maps the path of each merged value, like "aws.region", to
+		// the name of the source which has set it
+		Sources map[string]string
+		// ListSources maps the path of each merged list to the names of all the
+		// sources which have contributed to its items, by increasing precedence
+		ListSources map[string][]string
+		// TypeChanges are the values overridden with a value of another type
+		TypeChanges []ParametersTypeChange
+	}
+
+	// ParametersTypeChange represents a value overridden by a value of another type
+	ParametersTypeChange struct {
+		// Path is the path of the overridden value, like "aws.region"
+		Path string
+		// From is the name of the source of the overridden value
+		From string
+		// FromType is the type of the overridden value
+		FromType string
+		// To is the name of the source of the overriding value
+		To string
+		// ToType is the type of the overriding value
+		ToType string
+	}
+)
+
+// FileParametersSource returns the source of the parameters read from the
+// given yaml file
+func FileParametersSource(path string) (ParametersSource, error) {
+	p, err := ParseParameters(path)
+	if err != nil {
+		return ParametersSource{}, err
+	}
+	return ParametersSource{Name: path, Parameters: p}, nil
+}
+
+// FileParametersSources returns the sources of the parameters read from the
+// given yaml files
+func FileParametersSources(paths ...string) ([]ParametersSource, error) {
+	res := make([]ParametersSource, 0, len(paths))
+	for _, path := range paths {
+		s, err := FileParametersSource(path)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// MergeParameters merges the given sources of parameters, sorted by
+// increasing precedence, for example: the global defaults, the region ones,
+// the qualifier ones and then the command line overrides.
+//
+// The sources are merged like the parameters of the customized environments:
+// the maps are deeply merged, the lists are concatenated and the other
+// values are overridden by the sources of higher precedence.
+func MergeParameters(sources ...ParametersSource) LayeredParameters {
+	res := LayeredParameters{
+		Parameters:  make(Parameters),
+		Sources:     make(map[string]string),
+		ListSources: make(map[string][]string),
+		TypeChanges: make([]ParametersTypeChange, 0),
+	}
+	types := make(map[string]string)
+	typeSources := make(map[string]string)
+	for _, s := range sources {
+		raw := make(map[string]string)
+		parametersTypes("", map[string]interface{}(s.Parameters), raw)
+		sTypes := make(map[string]string, len(raw))
+		for path, t := range raw {
+			if name, ok := mergedPath(path); ok {
+				sTypes[name] = t
+			}
+		}
+		for _, path := range sortedKeys(sTypes) {
+			if t, ok := types[path]; ok && t != sTypes[path] {
+				res.TypeChanges = append(res.TypeChanges, ParametersTypeChange{
+					Path:     path,
+					From:     typeSources[path],
+					FromType: t,
+					To:       s.Name,
+					ToType:   sTypes[path],
+				})
+			}
+		}
+		for path, t := range sTypes {
+			types[path] = t
+			typeSources[path] = s.Name
+		}
+
+		leaves := make(map[string]interface{})
+		flattenInto(leaves, "", map[string]interface{}(s.Parameters))
+		for path := range leaves {
+			if name, ok := mergedPath(path); ok {
+				res.Sources[name[1:]] = s.Name
+			}
+		}
+		previous := make(map[string]interface{})
+		flattenInto(previous, "", map[string]interface{}(res.Parameters))
+		res.Parameters = s.Parameters.inherit(res.Parameters)
+		res.trackLists(s.Name, leaves, previous)
+
+		// The values deleted or replaced by the source have no more source nor type
+		merged := make(map[string]string)
+		parametersTypes("", map[string]interface{}(res.Parameters), merged)
+		for path := range types {
+			if _, ok := merged[path]; !ok {
+				delete(types, path)
+				delete(typeSources, path)
+			}
+		}
+		for path := range res.Sources {
+			if _, ok := merged[path]; !ok {
+				delete(res.Sources, path)
+			}
+		}
+	}
+	return res
+}
+
+// mergedPath returns the path, without its merge directives, of the value
+// located by the given one, or false if the value is deleted using the
+// "!delete" directive
+func mergedPath(path string) (string, bool) {
+	if _, d := splitDirective(path); d == DirectiveDelete {
+		return "", false
+	}
+	return withoutDirectives(path), true
+}
+
+// trackLists records the source as contributing to the merged lists it
+// holds, the lists it has not concatenated to the previous ones being only
+// made of its items
+func (r *LayeredParameters) trackLists(source string, leaves, previous map[string]interface{}) {
+	merged := make(map[string]interface{})
+	flattenInto(merged, "", map[string]interface{}(r.Parameters))
+	for path := range r.ListSources {
+		// The lists overridden or deleted by the source
+		if _, ok := merged["."+path].([]interface{}); !ok {
+			delete(r.ListSources, path)
+		}
+	}
+	for path, v := range leaves {
+		l, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		name := withoutDirectives(path)
+		ml, ok := merged[name].([]interface{})
+		if !ok {
+			continue
+		}
+		pl, concatenated := previous[name].([]interface{})
+		concatenated = concatenated && !strings.HasSuffix(path, DirectiveReplace) &&
+			len(ml) == len(pl)+len(l) && reflect.DeepEqual(pl, ml[:len(pl)])
+		switch {
+		case concatenated && len(l) == 0:
+			// The source doesn't contribute to the list
+		case concatenated && len(pl) > 0:
+			r.ListSources[name[1:]] = append(r.ListSources[name[1:]], source)
+		default:
+			r.ListSources[name[1:]] = []string{source}
+		}
+	}
+}
+
+// MergeParameterSources merges the given sources of parameters, sorted by
+// increasing precedence, into the vars of the context.
+//
+// See MergeParameters.
+func (cc *TemplateContext) MergeParameterSources(sources ...ParametersSource) LayeredParameters {
+	res := MergeParameters(sources...)
+	cc.mergeVars(res.Parameters)
+	return res
+}
+
+// parametersTypes records the type of all the values of the parameters tree,
+// indexed by their path
+func parametersTypes(path string, v interface{}, types map[string]string) {
+	child := func(k interface{}) string {
+		if path == "" {
+			return fmt.Sprintf("%v", k)
+		}
+		return fmt.Sprintf("%s.%v", path, k)
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, e := range val {
+			parametersTypes(child(k), e, types)
+		}
+		if path != "" {
+			types[path] = "map"
+		}
+	case map[interface{}]interface{}:
+		for k, e := range val {
+			parametersTypes(child(k), e, types)
+		}
+		types[path] = "map"
+	case Parameters:
+		parametersTypes(path, map[string]interface{}(val), types)
+	case []interface{}, []string:
+		types[path] = "list"
+	case string:
+		types[path] = "string"
+	case bool:
+		types[path] = "bool"
+	case int, int64:
+		types[path] = "int"
+	case float64:
+		types[path] = "float"
+	case nil:
+	default:
+		types[path] = fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeParameters(t *testing.T) {
+	sources, err := FileParametersSources(
+		"./testdata/params/defaults.yaml",
+		"./testdata/params/region.yaml",
+		"./testdata/params/qualifier.yaml")
+	assert.Nil(t, err)
+	sources = append(sources, ParametersSource{
+		Name:       "command line",
+		Parameters: CreateParameters(map[string]interface{}{"region": "us-east-1"}),
+	})
+
+	res := MergeParameters(sources...)
+	p := res.Parameters
+	assert.Equal(t, "us-east-1", p["region"])
+	assert.Equal(t, "3", p["instances"])
+	aws := p["aws"].(map[string]interface{})
+	assert.Equal(t, "t2.large", aws["instance_type"])
+	assert.Equal(t, []interface{}{"ekara", "paris"}, aws["tags"])
+	assert.Equal(t, []interface{}{"eu-west-3a", "eu-west-3b"}, p["zones"])
+
+	assert.Equal(t, "command line", res.Sources["region"])
+	assert.Equal(t, "./testdata/params/qualifier.yaml", res.Sources["instances"])
+	assert.Equal(t, "./testdata/params/qualifier.yaml", res.Sources["aws.instance_type"])
+	assert.Equal(t, "./testdata/params/region.yaml", res.Sources["aws.tags"])
+	assert.Equal(t, "./testdata/params/region.yaml", res.Sources["zones"])
+	// The concatenated lists keep all their sources
+	assert.Equal(t, []string{"./testdata/params/defaults.yaml", "./testdata/params/region.yaml"}, res.ListSources["aws.tags"])
+	assert.Equal(t, []string{"./testdata/params/region.yaml"}, res.ListSources["zones"])
+
+	if assert.Equal(t, 2, len(res.TypeChanges)) {
+		assert.Equal(t, ParametersTypeChange{
+			Path:     "zones",
+			From:     "./testdata/params/defaults.yaml",
+			FromType: "string",
+			To:       "./testdata/params/region.yaml",
+			ToType:   "list",
+		}, res.TypeChanges[0])
+		assert.Equal(t, ParametersTypeChange{
+			Path:     "instances",
+			From:     "./testdata/params/defaults.yaml",
+			FromType: "int",
+			To:       "./testdata/params/qualifier.yaml",
+			ToType:   "string",
+		}, res.TypeChanges[1])
+	}
+}
+
+func TestMergeParametersListSources(t *testing.T) {
+	source := func(name string, p map[string]interface{}) ParametersSource {
+		return ParametersSource{Name: name, Parameters: CreateParameters(p)}
+	}
+	res := MergeParameters(
+		source("first", map[string]interface{}{
+			"tags":     []interface{}{"a"},
+			"cidrs":    []interface{}{"10.0.0.0/8"},
+			"zones":    []interface{}{"z1"},
+			"replaced": map[string]interface{}{"items": []interface{}{"x"}},
+		}),
+		source("second", map[string]interface{}{
+			"tags":          []interface{}{"b"},
+			"cidrs!replace": []interface{}{"10.0.0.0/8", "192.168.0.0/16"},
+			"zones!delete":  true,
+			"replaced":      "scalar",
+		}),
+		source("third", map[string]interface{}{
+			"tags":         []interface{}{},
+			"cidrs":        []interface{}{"172.16.0.0/12"},
+			"zones":        []interface{}{"z2"},
+			"replaced":     map[string]interface{}{"items": []interface{}{"y"}},
+			"ports!append": []interface{}{80},
+		}),
+	)
+	assert.Equal(t, []string{"first", "second"}, res.ListSources["tags"])
+	assert.Equal(t, []string{"second", "third"}, res.ListSources["cidrs"])
+	assert.Equal(t, []string{"third"}, res.ListSources["zones"])
+	assert.Equal(t, []string{"third"}, res.ListSources["replaced.items"])
+	assert.Equal(t, []string{"third"}, res.ListSources["ports"])
+	assert.Equal(t, 5, len(res.ListSources))
+}
+
+func TestMergeParametersDirectives(t *testing.T) {
+	source := func(name string, p map[string]interface{}) ParametersSource {
+		return ParametersSource{Name: name, Parameters: CreateParameters(p)}
+	}
+	res := MergeParameters(
+		source("first", map[string]interface{}{
+			"cidrs":  []interface{}{"a"},
+			"zones":  "z",
+			"limits": map[string]interface{}{"cpu": 1, "memory": 512},
+		}),
+		source("second", map[string]interface{}{
+			"cidrs!replace":  []interface{}{"b"},
+			"zones!delete":   true,
+			"limits!replace": map[string]interface{}{"cpu": "2"},
+		}),
+	)
+	assert.Equal(t, Parameters{
+		"cidrs":  []interface{}{"b"},
+		"limits": map[string]interface{}{"cpu": "2"},
+	}, res.Parameters)
+	// The values are credited to the source of the directives, the deleted ones have no source
+	assert.Equal(t, map[string]string{"cidrs": "second", "limits.cpu": "second"}, res.Sources)
+	assert.Equal(t, map[string][]string{"cidrs": {"second"}}, res.ListSources)
+	if assert.Equal(t, 1, len(res.TypeChanges)) {
+		assert.Equal(t, ParametersTypeChange{Path: "limits.cpu", From: "first", FromType: "int", To: "second", ToType: "string"}, res.TypeChanges[0])
+	}
+
+	// A deleted value set again is not a type change
+	res = MergeParameters(
+		source("first", map[string]interface{}{"zones": "z"}),
+		source("second", map[string]interface{}{"zones!delete": true}),
+		source("third", map[string]interface{}{"zones": []interface{}{"z1"}}),
+	)
+	assert.Equal(t, map[string]string{"zones": "third"}, res.Sources)
+	assert.Equal(t, 0, len(res.TypeChanges))
+}
+
+func TestMergeParameterSourcesIntoContext(t *testing.T) {
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{
+		"region": "initial",
+		"other":  "value",
+	}))
+	ctx.MergeParameterSources(
+		ParametersSource{Name: "first", Parameters: CreateParameters(map[string]interface{}{"region": "first"})},
+		ParametersSource{Name: "second", Parameters: CreateParameters(map[string]interface{}{"region": "second"})},
+	)
+	assert.Equal(t, "second", ctx.Vars["region"])
+	assert.Equal(t, "value", ctx.Vars["other"])
+}
+
+func TestFileParametersSourceMissing(t *testing.T) {
+	_, err := FileParametersSources("./testdata/params/defaults.yaml", "./testdata/params/missing.yaml")
+	assert.NotNil(t, err)
+}
@@ -1,10 +1,13 @@
 package model
 
 import (
+	"encoding/json"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 func TestSliceConcatenation(t *testing.T) {
@@ -15,7 +18,7 @@ func TestSliceConcatenation(t *testing.T) {
 		"slice": []int{4, 5, 6},
 	})
 	res := child.inherit(parent)
-	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, res["slice"])
+	assert.Equal(t, []interface{}{1, 2, 3, 4, 5, 6}, res["slice"])
 }
 
 func TestCloneParameters(t *testing.T) {
@@ -34,7 +37,7 @@ func TestCloneParameters(t *testing.T) {
 	require.Equal(t, map[string]interface{}{"a": "zzz"}, m1)
 	require.Equal(t, Parameters{
 		"a": "bbb",
-		"b": Parameters{
+		"b": map[string]interface{}{
 			"c": 123,
 		},
 	}, m2)
@@ -47,8 +50,16 @@ func TestSliceMismatch(t *testing.T) {
 	child := CreateParameters(map[string]interface{}{
 		"slice": []int{4, 5, 6},
 	})
+	// The lists are concatenated whatever the type of their items
 	res := child.inherit(parent)
-	assert.Equal(t, []int{4, 5, 6}, res["slice"])
+	assert.Equal(t, []interface{}{"a", "b", "c", 4, 5, 6}, res["slice"])
+
+	// Unless the replacement is explicitly required
+	child = CreateParameters(map[string]interface{}{
+		"slice!replace": []int{4, 5, 6},
+	})
+	res = child.inherit(parent)
+	assert.Equal(t, []interface{}{4, 5, 6}, res["slice"])
 }
 
 func TestMapMerging(t *testing.T) {
@@ -65,8 +76,208 @@ func TestMapMerging(t *testing.T) {
 		"key2": "unrelatedValue",
 	})
 	res := child.inherit(parent)
-	assert.Equal(t, "someValue", (res["key1"]).(map[interface{}]interface{})["key11"])
-	assert.Equal(t, "otherValue", (res["key1"]).(map[interface{}]interface{})["key12"])
-	assert.Equal(t, "thirdValue", (res["key1"]).(map[interface{}]interface{})["key13"])
+	assert.Equal(t, "someValue", (res["key1"]).(map[string]interface{})["key11"])
+	assert.Equal(t, "otherValue", (res["key1"]).(map[string]interface{})["key12"])
+	assert.Equal(t, "thirdValue", (res["key1"]).(map[string]interface{})["key13"])
 	assert.Equal(t, "unrelatedValue", res["key2"])
 }
+
+func accessorParameters() Parameters {
+	p := make(Parameters)
+	p["name"] = "ekara"
+	p["replicas"] = 3
+	p["templated"] = "5"
+	p["ratio"] = 1.5
+	p["debug"] = "true"
+	p["timeout"] = "1m30s"
+	p["delay"] = 10
+	p["zones"] = []interface{}{"a", "b", 3}
+	p["yaml"] = map[interface{}]interface{}{
+		"db": map[interface{}]interface{}{"port": 5432, 1: "one"},
+	}
+	p["inherited"] = map[string]interface{}{"user": "admin"}
+	return p
+}
+
+func TestParametersAccessors(t *testing.T) {
+	p := accessorParameters()
+
+	s, err := p.String("name")
+	assert.Nil(t, err)
+	assert.Equal(t, "ekara", s)
+	s, err = p.String("yaml.db.port")
+	assert.Nil(t, err)
+	assert.Equal(t, "5432", s)
+	s, err = p.String("yaml.db.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "one", s)
+	s, err = p.String("inherited.user")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", s)
+	s, err = p.String("zones.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", s)
+
+	i, err := p.Int("replicas")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, i)
+	i, err = p.Int("templated")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, i)
+
+	b, err := p.Bool("debug")
+	assert.Nil(t, err)
+	assert.True(t, b)
+
+	d, err := p.Duration("timeout")
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Second, d)
+	d, err = p.Duration("delay")
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Second, d)
+
+	ss, err := p.StringSlice("zones")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "3"}, ss)
+
+	m, err := p.Map("yaml.db")
+	assert.Nil(t, err)
+	assert.Equal(t, 5432, m["port"])
+	m, err = p.Map("inherited")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", m["user"])
+
+	assert.True(t, p.Has("yaml.db.port"))
+	assert.True(t, p.Has("zones.2"))
+	assert.False(t, p.Has("yaml.db.host"))
+	assert.False(t, p.Has("zones.3"))
+	assert.False(t, p.Has("name.sub"))
+	assert.False(t, p.Has(""))
+}
+
+func TestParametersAccessorsErrors(t *testing.T) {
+	p := accessorParameters()
+
+	_, err := p.String("missing.key")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "parameter missing.key not found", err.Error())
+	}
+	_, err = p.Int("ratio")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "invalid parameter ratio: int expected, got float", err.Error())
+	}
+	_, err = p.Int("name")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "invalid parameter name: int expected, got string", err.Error())
+	}
+	_, err = p.Bool("replicas")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "invalid parameter replicas: bool expected, got int", err.Error())
+	}
+	_, err = p.Duration("name")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "invalid parameter name: duration expected, got string", err.Error())
+	}
+	_, err = p.String("yaml")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "invalid parameter yaml: string expected, got map", err.Error())
+	}
+	_, err = p.StringSlice("name")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "invalid parameter name: list of strings expected, got string", err.Error())
+	}
+	_, err = p.Map("zones")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "invalid parameter zones: map expected, got list", err.Error())
+	}
+}
+
+func normalizationParameters() Parameters {
+	return CreateParameters(map[string]interface{}{
+		"string": "value",
+		"int":    int64(3),
+		"float":  1.5,
+		"round":  2.0,
+		"bool":   true,
+		"null":   nil,
+		"uint":   uint8(7),
+		"list":   []string{"a", "b"},
+		"nested": map[interface{}]interface{}{
+			1:      "one",
+			"list": []interface{}{map[interface{}]interface{}{"k": "v"}},
+			"params": Parameters{
+				"key": "value",
+			},
+		},
+	})
+}
+
+func TestParametersNormalized(t *testing.T) {
+	p := normalizationParameters()
+	assert.Equal(t, Parameters{
+		"string": "value",
+		"int":    3,
+		"float":  1.5,
+		"round":  2,
+		"bool":   true,
+		"null":   nil,
+		"uint":   7,
+		"list":   []interface{}{"a", "b"},
+		"nested": map[string]interface{}{
+			"1":    "one",
+			"list": []interface{}{map[string]interface{}{"k": "v"}},
+			"params": map[string]interface{}{
+				"key": "value",
+			},
+		},
+	}, p)
+
+	// The merged parameters remain normalized
+	child := CreateParameters(map[string]interface{}{
+		"nested": map[interface{}]interface{}{"added": "value"},
+	})
+	res := child.inherit(p)
+	nested, ok := res["nested"].(map[string]interface{})
+	if assert.True(t, ok) {
+		assert.Equal(t, "one", nested["1"])
+		assert.Equal(t, "value", nested["added"])
+	}
+	assert.Equal(t, CloneParameters(res), res)
+	assert.NotContains(t, Json(res), "error")
+}
+
+func TestParametersNormalizedUnsupported(t *testing.T) {
+	type ratio float32
+	type point struct{ X, Y int }
+	ch := make(chan int)
+	p := CreateParameters(map[string]interface{}{
+		"ratio": ratio(0.5),
+		"point": point{X: 1, Y: 2},
+		"chan":  ch,
+		"func":  func() {},
+	})
+	assert.Equal(t, 0.5, p["ratio"])
+	// The values without JSON representation are kept instead of being stringified
+	assert.Equal(t, point{X: 1, Y: 2}, p["point"])
+	assert.Equal(t, ch, p["chan"])
+	_, ok := p["func"].(func())
+	assert.True(t, ok)
+}
+
+func TestParametersJSONRoundTrip(t *testing.T) {
+	p := normalizationParameters()
+	b, err := json.Marshal(p)
+	require.Nil(t, err)
+	var res Parameters
+	require.Nil(t, json.Unmarshal(b, &res))
+	assert.Equal(t, p, res)
+}
+
+func TestParametersYAMLRoundTrip(t *testing.T) {
+	p := normalizationParameters()
+	b, err := yaml.Marshal(p)
+	require.Nil(t, err)
+	var res Parameters
+	require.Nil(t, yaml.Unmarshal(b, &res))
+	assert.Equal(t, p, res)
+}
@@ -7,8 +7,13 @@ import (
 //Parent Represents the parent used to run Ekara
 type Parent Component
 
-//CreateParent creates the parent
+//CreateParent creates the direct parent
 func CreateParent(base Base, yamlEkara yamlEkara) (Parent, bool, error) {
+	return createParent(base, yamlEkara, EkaraComponentId)
+}
+
+// createParent creates the parent, identified by the given id
+func createParent(base Base, yamlEkara yamlEkara, id string) (Parent, bool, error) {
 	repo := yamlEkara.Parent.Repository
 	if repo == "" {
 		//If the parent is not specified we return an nil parent
@@ -19,7 +24,7 @@ func CreateParent(base Base, yamlEkara yamlEkara) (Parent, bool, error) {
 		return Parent{}, false, errors.New("invalid parent repository: " + e.Error())
 	}
 	repoParent.setAuthentication(yamlEkara.Parent)
-	c := CreateComponent(EkaraComponentId, repoParent)
+	c := CreateComponent(id, repoParent)
 	return Parent(c), true, nil
 }
 
@@ -30,5 +35,5 @@ func (p Parent) Component() (Component, error) {
 
 //ComponentName returns the referenced component name
 func (p Parent) ComponentName() string {
-	return EkaraComponentId
+	return p.Id
 }
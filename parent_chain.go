@@ -0,0 +1,164 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxParentDepth is the maximum number of parents which can be chained,
+	// a distribution extending another one
+	MaxParentDepth = 10
+)
+
+type (
+	// ParentChain represents the parents of a descriptor, from its direct
+	// parent to the farthest one
+	ParentChain []Parent
+
+	// ParentResolver returns the references of the descriptor held by the given parent
+	ParentResolver func(p Parent) (EnvironmentReferences, error)
+)
+
+// ParentComponentId returns the id of the parent located at the given depth
+// into a parent chain.
+//
+// The direct parent, at depth 0, keeps the id EkaraComponentId; the next ones
+// are suffixed with their depth, like "__ekara__1".
+func ParentComponentId(depth int) string {
+	if depth == 0 {
+		return EkaraComponentId
+	}
+	return EkaraComponentId + strconv.Itoa(depth)
+}
+
+// ParentDepth returns the depth, into a parent chain, of the parent
+// identified by the given id and false if the id doesn't identify a parent
+func ParentDepth(id string) (int, bool) {
+	if !strings.HasPrefix(id, EkaraComponentId) {
+		return 0, false
+	}
+	suffix := strings.TrimPrefix(id, EkaraComponentId)
+	if suffix == "" {
+		return 0, true
+	}
+	d, err := strconv.Atoi(suffix)
+	if err != nil || d <= 0 || strconv.Itoa(d) != suffix {
+		return 0, false
+	}
+	return d, true
+}
+
+// Parents returns the whole chain of parents of the descriptor, from its
+// direct parent to the farthest one.
+//
+// The resolver is used to get the references of the descriptor held by each
+// parent, in order to get its own parent. An error is returned if the same
+// repository is found twice into the chain or if the chain is longer than
+// MaxParentDepth.
+func (er EnvironmentReferences) Parents(resolve ParentResolver) (ParentChain, error) {
+	res := ParentChain{}
+	refs := er
+	for {
+		parent, ok, err := refs.parentAt(len(res))
+		if err != nil || !ok {
+			return res, err
+		}
+		for i, p := range res {
+			if p.key() == parent.key() {
+				return res, fmt.Errorf("cyclic parent chain: %s", res[i:].path(parent))
+			}
+		}
+		if len(res) == MaxParentDepth {
+			return res, fmt.Errorf("the parent chain exceeds the maximum depth of %d: %s", MaxParentDepth, res.path(parent))
+		}
+		res = append(res, parent)
+		refs, err = resolve(parent)
+		if err != nil {
+			return res, fmt.Errorf("unable to read the parent %s: %s", parent.key(), err.Error())
+		}
+	}
+}
+
+// parentAt returns the parent of the descriptor, identified according to its
+// depth into the parent chain
+func (er EnvironmentReferences) parentAt(depth int) (Parent, bool, error) {
+	parentBase, err := CreateBase(er.Ekara.Base)
+	if err != nil {
+		return Parent{}, false, err
+	}
+	return createParent(parentBase, er.Ekara, ParentComponentId(depth))
+}
+
+// CustomizationOrder returns the parents in the order where they must
+// customize the environment, the farthest one first
+func (c ParentChain) CustomizationOrder() []Parent {
+	res := make([]Parent, 0, len(c))
+	for i := len(c) - 1; i >= 0; i-- {
+		res = append(res, c[i])
+	}
+	return res
+}
+
+// Ids returns the ids of the parents of the chain, from the direct parent
+// to the farthest one
+func (c ParentChain) Ids() []string {
+	res := make([]string, 0, len(c))
+	for _, p := range c {
+		res = append(res, p.Id)
+	}
+	return res
+}
+
+// path returns the repositories of the chain followed by the given parent
+func (c ParentChain) path(next Parent) string {
+	keys := make([]string, 0, len(c)+1)
+	for _, p := range c {
+		keys = append(keys, p.key())
+	}
+	return strings.Join(append(keys, next.key()), " -> ")
+}
+
+// key returns the repository and the ref identifying the parent
+func (p Parent) key() string {
+	res := ""
+	if p.Repository.Url != nil {
+		res = p.Repository.Url.String()
+	}
+	if p.Repository.Ref != "" {
+		res = res + "@" + p.Repository.Ref
+	}
+	return res
+}
+
+// checkCustomizationOrder returns an error if the environment cannot be
+// customized by the given component at this point.
+//
+// The parents must customize the environment once, from the farthest one to
+// the direct one, and before the main descriptor.
+func (r *Environment) checkCustomizationOrder(from Component) error {
+	depth, isParent := ParentDepth(from.Id)
+	if !isParent && from.Id != MainComponentId {
+		return nil
+	}
+	for _, id := range r.customizedBy {
+		if id == from.Id {
+			return fmt.Errorf("the environment has already been customized by %s", from.Id)
+		}
+	}
+	if !isParent {
+		return nil
+	}
+	for _, id := range r.customizedBy {
+		if id == MainComponentId {
+			return fmt.Errorf("the parent %s cannot customize the environment after the main descriptor", from.Id)
+		}
+	}
+	for _, id := range r.customizedBy {
+		if d, ok := ParentDepth(id); ok && d < depth {
+			return fmt.Errorf("the parent %s must customize the environment before the parent %s", from.Id, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chainResolver resolves the parents from the descriptors located into testdata/yaml/chain
+func chainResolver(t *testing.T) ParentResolver {
+	return func(p Parent) (EnvironmentReferences, error) {
+		return ParseYamlDescriptorReferences(buildURL(t, chainDescriptor(p)), &TemplateContext{})
+	}
+}
+
+func chainDescriptor(p Parent) string {
+	return "./testdata/yaml/chain/" + strings.TrimSuffix(path.Base(p.Repository.Url.String()), ".git") + ".yaml"
+}
+
+func TestParentComponentIds(t *testing.T) {
+	assert.Equal(t, EkaraComponentId, ParentComponentId(0))
+	assert.Equal(t, EkaraComponentId+"2", ParentComponentId(2))
+
+	d, ok := ParentDepth(ParentComponentId(0))
+	assert.True(t, ok)
+	assert.Equal(t, 0, d)
+	d, ok = ParentDepth(ParentComponentId(3))
+	assert.True(t, ok)
+	assert.Equal(t, 3, d)
+	_, ok = ParentDepth(MainComponentId)
+	assert.False(t, ok)
+	_, ok = ParentDepth(EkaraComponentId + "01")
+	assert.False(t, ok)
+}
+
+func TestParentChain(t *testing.T) {
+	refs, e := ParseYamlDescriptorReferences(buildURL(t, "./testdata/yaml/chain/main.yaml"), &TemplateContext{})
+	require.Nil(t, e)
+
+	chain, e := refs.Parents(chainResolver(t))
+	require.Nil(t, e)
+	if assert.Equal(t, 2, len(chain)) {
+		assert.Equal(t, []string{ParentComponentId(0), ParentComponentId(1)}, chain.Ids())
+		assert.Equal(t, "./testdata/yaml/chain/distrib1.yaml", chainDescriptor(chain[0]))
+		assert.Equal(t, "./testdata/yaml/chain/distrib2.yaml", chainDescriptor(chain[1]))
+		assert.Equal(t, ParentComponentId(1), chain[1].ComponentName())
+	}
+
+	// The direct parent remains available alone
+	p, ok, e := refs.Parent()
+	require.Nil(t, e)
+	assert.True(t, ok)
+	assert.Equal(t, chain[0], p)
+
+	// The parents are applied from the farthest one
+	env := InitEnvironment()
+	for _, p := range chain.CustomizationOrder() {
+		yamlEnv, e := ParseYamlDescriptor(buildURL(t, chainDescriptor(p)), &TemplateContext{})
+		require.Nil(t, e)
+		pp, e := createPlatform(yamlEnv.Ekara)
+		require.Nil(t, e)
+		penv, e := CreateEnvironment(p.Id, yamlEnv, p.Id)
+		require.Nil(t, e)
+		penv.ekara = &pp
+		require.Nil(t, env.Customize(Component(p), penv))
+	}
+	require.Nil(t, env.Customize(Component{Id: MainComponentId}, buildEnvironment(t, "./testdata/yaml/chain/main.yaml")))
+	assert.Equal(t, "distrib2", env.Vars["distrib2"])
+	assert.Equal(t, "distrib1", env.Vars["distrib1"])
+	assert.Equal(t, "main", env.Vars["main"])
+}
+
+func TestParentChainWithoutParent(t *testing.T) {
+	refs, e := ParseYamlDescriptorReferences(buildURL(t, "./testdata/yaml/chain/distrib2.yaml"), &TemplateContext{})
+	require.Nil(t, e)
+	chain, e := refs.Parents(chainResolver(t))
+	assert.Nil(t, e)
+	assert.Equal(t, 0, len(chain))
+}
+
+func TestParentChainCycle(t *testing.T) {
+	refs, e := ParseYamlDescriptorReferences(buildURL(t, "./testdata/yaml/chain/cycle1.yaml"), &TemplateContext{})
+	require.Nil(t, e)
+	_, e = refs.Parents(chainResolver(t))
+	if assert.NotNil(t, e) {
+		assert.Equal(t, "cyclic parent chain: https://github.com/ekara-platform/cycle2.git -> https://github.com/ekara-platform/cycle1.git -> https://github.com/ekara-platform/cycle2.git", e.Error())
+	}
+}
+
+func TestParentChainMaxDepth(t *testing.T) {
+	// Each distribution extends a new one
+	resolve := func(p Parent) (EnvironmentReferences, error) {
+		d, _ := ParentDepth(p.Id)
+		content := fmt.Sprintf("ekara:\n  parent:\n    repository: ekara-platform/distrib%d\n", d+1)
+		return ParseYamlDescriptorReferencesBytes(p.Id, []byte(content), &TemplateContext{})
+	}
+	refs, e := ParseYamlDescriptorReferencesBytes("main", []byte("ekara:\n  parent:\n    repository: ekara-platform/distrib0\n"), &TemplateContext{})
+	require.Nil(t, e)
+	chain, e := refs.Parents(resolve)
+	if assert.NotNil(t, e) {
+		assert.Contains(t, e.Error(), fmt.Sprintf("the parent chain exceeds the maximum depth of %d", MaxParentDepth))
+	}
+	assert.Equal(t, MaxParentDepth, len(chain))
+}
+
+func TestParentChainCustomizationOrder(t *testing.T) {
+	env := InitEnvironment()
+	require.Nil(t, env.Customize(Component{Id: ParentComponentId(1)}, InitEnvironment()))
+	require.Nil(t, env.Customize(Component{Id: ParentComponentId(0)}, InitEnvironment()))
+
+	e := env.Customize(Component{Id: ParentComponentId(1)}, InitEnvironment())
+	if assert.NotNil(t, e) {
+		assert.Equal(t, "the environment has already been customized by __ekara__1", e.Error())
+	}
+	e = env.Customize(Component{Id: ParentComponentId(2)}, InitEnvironment())
+	if assert.NotNil(t, e) {
+		assert.Equal(t, "the parent __ekara__2 must customize the environment before the parent __ekara__1", e.Error())
+	}
+
+	require.Nil(t, env.Customize(Component{Id: MainComponentId}, InitEnvironment()))
+	e = env.Customize(Component{Id: ParentComponentId(3)}, InitEnvironment())
+	if assert.NotNil(t, e) {
+		assert.Equal(t, "the parent __ekara__3 cannot customize the environment after the main descriptor", e.Error())
+	}
+	e = env.Customize(Component{Id: MainComponentId}, InitEnvironment())
+	if assert.NotNil(t, e) {
+		assert.Equal(t, "the environment has already been customized by __main__", e.Error())
+	}
+
+	// Any other component can customize the environment at any time
+	assert.Nil(t, env.Customize(Component{Id: "other"}, InitEnvironment()))
+	assert.Nil(t, env.Customize(Component{Id: "other"}, InitEnvironment()))
+}
+
+func TestParentChainIntoPlatform(t *testing.T) {
+	refs, e := ParseYamlDescriptorReferences(buildURL(t, "./testdata/yaml/chain/main.yaml"), &TemplateContext{})
+	require.Nil(t, e)
+	chain, e := refs.Parents(chainResolver(t))
+	require.Nil(t, e)
+
+	env := InitEnvironment()
+	for _, p := range chain.CustomizationOrder() {
+		require.Nil(t, env.Customize(Component(p), buildEnvironment(t, chainDescriptor(p))))
+	}
+	// Each parent completes the chain with its own parent
+	if assert.Equal(t, 2, len(env.Platform().Parents)) {
+		assert.Equal(t, chain[1], env.Platform().Parents[1])
+	}
+	main := buildEnvironment(t, "./testdata/yaml/chain/main.yaml")
+	// The platform of a descriptor only knows its direct parent
+	assert.Equal(t, []string{EkaraComponentId}, main.Platform().Parents.Ids())
+	require.Nil(t, env.Customize(Component{Id: MainComponentId}, main))
+
+	p := env.Platform()
+	assert.Equal(t, chain, p.Parents)
+	assert.Equal(t, []string{EkaraComponentId, EkaraComponentId + "1"}, p.Parents.Ids())
+	assert.True(t, p.HasParent)
+	assert.Equal(t, chain[0], p.Parent)
+}
@@ -6,9 +6,13 @@ import (
 
 //Platform the platform used to build an environment
 type Platform struct {
-	Base       Base
-	Parent     Parent
-	HasParent  bool
+	Base Base
+	// Parent is the direct parent, the first one of Parents
+	Parent    Parent
+	HasParent bool
+	// Parents is the whole chain of parents, from the direct parent to the
+	// farthest one, completed by the parents customizing the environment
+	Parents    ParentChain
 	Components map[string]Component
 	Templates  Patterns
 	Playbooks  map[string]string
@@ -30,6 +34,9 @@ func createPlatform(yamlEkara yamlEkara) (Platform, error) {
 	}
 	p.HasParent = hasParent
 	p.Parent = parent
+	if hasParent {
+		p.Parents = ParentChain{parent}
+	}
 
 	// Store templates in the environment
 	p.Templates = yamlEkara.Templates
@@ -69,6 +76,29 @@ func (p Platform) KeepTemplates(c Component, templates Patterns) {
 	}
 }
 
+// chainParent records, into the chain of parents, the direct parent of the
+// descriptor held by the given component, once identified according to its
+// depth into the chain
+func (p *Platform) chainParent(from Component, parent Parent) {
+	depth := 0
+	if from.Id != MainComponentId {
+		d, ok := ParentDepth(from.Id)
+		if !ok {
+			return
+		}
+		depth = d + 1
+	}
+	parent.Id = ParentComponentId(depth)
+	for len(p.Parents) <= depth {
+		p.Parents = append(p.Parents, Parent{})
+	}
+	p.Parents[depth] = parent
+	if depth == 0 {
+		p.Parent = parent
+		p.HasParent = true
+	}
+}
+
 //AddComponent Add the given component to the platform
 func (p *Platform) AddComponent(c Component) {
 	p.Components[c.Id] = c
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+)
+
+type (
+	// Origin represents the descriptor which has set a value of the environment
+	Origin struct {
+		// Component is the id of the component holding the descriptor, it
+		// matches the ID of the corresponding environment Parcel
+		Component string
+		// Location is the location of the value into the descriptor
+		Location DescriptorLocation
+		// Value is the value set by the descriptor
+		Value interface{} `json:",omitempty"`
+	}
+
+	// Provenance keeps track of the descriptors which have set the values of
+	// a customized environment.
+	//
+	// The origins are indexed by the path of the values into the descriptor,
+	// for example "nodes.node1.instances" or "providers.aws.params.region".
+	// The origins of the providers, node sets, stacks and tasks themselves
+	// are indexed by their path, for example "nodes.node1".
+	//
+	// For each path the origins are sorted in the order of the customization, the
+	// last one being the origin of the resolved value.
+	Provenance map[string][]Origin
+)
+
+// Provenance returns the origins of all the values of the environment.
+//
+// The sensitive values are masked unless ShowSensitive has been enabled.
+func (r *Environment) Provenance() Provenance {
+	red := r.redactor()
+	if red.disabled() {
+		return r.provenance
+	}
+	res := make(Provenance, len(r.provenance))
+	for path, chain := range r.provenance {
+		res[path] = red.origins(chain)
+	}
+	return res
+}
+
+// Blame returns the origin of the resolved value located by the given path.
+//
+// If the value itself has no origin, for example because it has never been
+// set, then the origin of its closest parent will be returned.
+func (p Provenance) Blame(path string) (Origin, bool) {
+	chain := p.Chain(path)
+	if len(chain) == 0 {
+		return Origin{}, false
+	}
+	return chain[len(chain)-1], true
+}
+
+// Chain returns all the origins of the value located by the given path, the
+// last one being the origin of the resolved value.
+//
+// If the value itself has no origin, for example because it has never been
+// set, then the origins of its closest parent will be returned.
+func (p Provenance) Chain(path string) []Origin {
+	for path != "" {
+		if o, ok := p[path]; ok {
+			return o
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return []Origin{}
+}
+
+// attribute returns the given validation errors located into the descriptors
+// which have introduced the invalid values.
+//
+// The errors without known origin remain unchanged.
+func (p Provenance) attribute(vErrs ValidationErrors) ValidationErrors {
+	res := ValidationErrors{}
+	for _, e := range vErrs.Errors {
+		chain := p.Chain(e.Location.Path)
+		if len(chain) > 0 {
+			last := chain[len(chain)-1]
+			e.Component = last.Component
+			e.Origins = chain
+			e.Location = DescriptorLocation{
+				Descriptor: last.Location.Descriptor,
+				Path:       e.Location.Path,
+				positions:  last.Location.positions,
+			}.located()
+		}
+		res.Errors = append(res.Errors, e)
+	}
+	return res
+}
+
+// track records the origins of the values set by the customization of the
+// environment with the one coming from the given component.
+//
+// A value is considered as set by the customization if it is defined into
+// the customizing environment and if it either matches the resolved value or
+// has changed the previously resolved value.
+func (p Provenance) track(from Component, with *Environment, before, after map[string]interface{}) {
+	withLeaves := with.leaves()
+	for path, v := range after {
+		wv, ok := withLeaves[path]
+		if !ok {
+			continue
+		}
+		if reflect.DeepEqual(wv, v) || !reflect.DeepEqual(before[path], v) {
+			p[path] = append(p[path], Origin{Component: from.Id, Location: with.locate(path), Value: v})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			delete(p, path)
+		}
+	}
+	for _, prefix := range []string{"providers", "nodes", "stacks", "tasks"} {
+		var names []string
+		switch prefix {
+		case "providers":
+			names = with.Providers.names()
+		case "nodes":
+			names = with.NodeSets.names()
+		case "stacks":
+			names = with.Stacks.names()
+		case "tasks":
+			names = with.Tasks.names()
+		}
+		for _, name := range names {
+			path := prefix + "." + name
+			p[path] = append(p[path], Origin{Component: from.Id, Location: with.locate(path)})
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenance(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/diff/from.yaml", "./testdata/yaml/diff/to.yaml")
+	assert.Nil(t, err)
+
+	p := env.Provenance()
+
+	// Value overwritten by the main descriptor
+	chain := p.Chain("nodes.node1.instances")
+	if assert.Equal(t, 2, len(chain)) {
+		assert.Equal(t, "parent", chain[0].Component)
+		assert.Equal(t, 10, chain[0].Value)
+		assert.Equal(t, "./testdata/yaml/diff/from.yaml", chain[0].Location.Descriptor)
+		assert.Equal(t, 34, chain[0].Location.Line)
+		assert.Equal(t, MainComponentId, chain[1].Component)
+		assert.Equal(t, 20, chain[1].Value)
+		assert.Equal(t, "./testdata/yaml/diff/to.yaml", chain[1].Location.Descriptor)
+	}
+
+	// Value only defined into the parent
+	o, ok := p.Blame("vars.removed_var")
+	assert.True(t, ok)
+	assert.Equal(t, "parent", o.Component)
+	assert.Equal(t, "value", o.Value)
+
+	// Node set only defined into the parent
+	o, ok = p.Blame("nodes.node2.instances")
+	assert.True(t, ok)
+	assert.Equal(t, "parent", o.Component)
+	assert.Equal(t, 1, len(p.Chain("nodes.node2")))
+
+	// Unset values are blamed on their closest parent
+	o, ok = p.Blame("nodes.node3.labels.unknown")
+	assert.True(t, ok)
+	assert.Equal(t, MainComponentId, o.Component)
+	assert.Equal(t, "nodes.node3", o.Location.Path)
+
+	_, ok = p.Blame("unknown.path")
+	assert.False(t, ok)
+	assert.Equal(t, 0, len(p.Chain("unknown.path")))
+}
+
+func TestValidationErrorProvenance(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/provenance/parent.yaml", "./testdata/yaml/provenance/main.yaml")
+	assert.Nil(t, err)
+
+	vErrs := env.Validate()
+	assert.True(t, vErrs.HasErrors())
+	errs := vErrs.locate("instances must be a positive number")
+	if assert.Equal(t, 1, len(errs)) {
+		e := errs[0]
+		// The error is reported into the parent which has set the invalid value
+		assert.Equal(t, "parent", e.Component)
+		assert.Equal(t, "./testdata/yaml/provenance/parent.yaml", e.Location.Descriptor)
+		assert.Equal(t, "nodes.node1.instances", e.Location.Path)
+		assert.Equal(t, 16, e.Location.Line)
+		assert.Equal(t, 5, e.Location.Column)
+		if assert.Equal(t, 1, len(e.Origins)) {
+			assert.Equal(t, -1, e.Origins[0].Value)
+		}
+		assert.Contains(t, vErrs.Error(), "parent.yaml:16:5 (parent)")
+	}
+}
@@ -10,6 +10,8 @@ type (
 	Provider struct {
 		// The component containing the provider
 		cRef componentRef
+		// The location of the provider into the descriptor
+		location DescriptorLocation
 		// The Name of the provider
 		Name string
 		// The provider parameters
@@ -90,6 +92,7 @@ func createProviders(env *Environment, location DescriptorLocation, yamlEnv *yam
 		proxy := createProxy(yamlProvider.Proxy)
 		res[name] = Provider{
 			Name:       name,
+			location:   providerLocation,
 			cRef:       createComponentRef(env, providerLocation.appendPath("component"), yamlProvider.Component, true),
 			Parameters: params,
 			EnvVars:    envVars,
@@ -119,3 +122,8 @@ func (r Providers) customize(env *Environment, with Providers) (Providers, error
 	}
 	return res, nil
 }
+
+// names returns the names of the providers in alphabetical order
+func (r Providers) names() []string {
+	return sortedKeys(r)
+}
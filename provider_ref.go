@@ -18,6 +18,18 @@ type (
 )
 
 func (r ProviderRef) MarshalYAML() (interface{}, error) {
+	params, envVars, proxy := r.parameters, r.envVars, r.proxy
+	if r.env != nil {
+		// The overriding values are masked like the ones of the provider. The
+		// reference being marshalled as a string, the values of the sensitive
+		// paths appearing within it are masked by the redactor of the
+		// environment, the base one is then enough here.
+		red := r.env.baseRedactor()
+		l := "providers." + r.ref
+		params = red.tree(l+".params", params).(Parameters)
+		envVars = red.tree(l+".env", envVars).(EnvVars)
+		proxy = red.proxy(proxy)
+	}
 	b, e := yaml.Marshal(&struct {
 		Ref        string
 		Parameters Parameters `yaml:",omitempty"`
@@ -25,9 +37,9 @@ func (r ProviderRef) MarshalYAML() (interface{}, error) {
 		Proxy      Proxy      `yaml:",omitempty"`
 	}{
 		Ref:        r.ref,
-		Parameters: r.parameters,
-		EnvVars:    r.envVars,
-		Proxy:      r.proxy,
+		Parameters: params,
+		EnvVars:    envVars,
+		Proxy:      proxy,
 	})
 	return string(b), e
 }
@@ -66,6 +78,7 @@ func (r ProviderRef) Resolve() (Provider, error) {
 	provider := r.env.Providers[r.ref]
 	return Provider{
 		Name:       provider.Name,
+		location:   provider.location,
 		cRef:       provider.cRef,
 		Parameters: r.parameters.inherit(provider.Parameters),
 		EnvVars:    r.envVars.inherit(provider.EnvVars),
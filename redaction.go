@@ -0,0 +1,339 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// minSensitiveValueLength is the minimal length of the sensitive values
+	// masked wherever they appear, the shorter ones are only masked by their path
+	minSensitiveValueLength = 4
+)
+
+var (
+	// sensitiveKeyPatterns are the parts of the keys denoting sensitive values
+	sensitiveKeyPatterns = []string{"password", "passwd", "secret", "token", "credential", "private_key", "privatekey", "access_key", "accesskey", "api_key", "apikey"}
+
+	// sensitiveContainers are the keys under which all the values are sensitive
+	sensitiveContainers = []string{"auth", "authentication"}
+
+	// proxyKeys are the keys holding proxy urls, which credentials are sensitive
+	proxyKeys = []string{"http_proxy", "https_proxy"}
+)
+
+type (
+	// redactor masks the sensitive content of an environment
+	redactor struct {
+		// enabled indicates if the redactor masks the sensitive content
+		enabled bool
+		// paths are the patterns of the paths declared as sensitive
+		paths []string
+		// values are the sensitive values, the longest first
+		values []string
+	}
+)
+
+// ShowSensitive allows to disable the masking of the sensitive values when
+// the environment is printed, exported, compared or validated.
+//
+// The sensitive values are the ones whose key looks like a password, a token,
+// a secret..., the authentication parameters, the credentials of the proxy
+// urls, the paths declared into the "sensitive" section of the descriptors and
+// the values added using AddSensitiveValues.
+//
+// The masking should only be disabled for trusted debugging.
+func (r *Environment) ShowSensitive(show bool) {
+	r.showSensitive = show
+}
+
+// AddSensitiveValues marks the given values, for example resolved secrets, as
+// sensitive wherever they appear into the environment
+func (r *Environment) AddSensitiveValues(values ...string) {
+	for _, v := range values {
+		if v != "" && !contains(r.sensitiveValues, v) {
+			r.sensitiveValues = append(r.sensitiveValues, v)
+		}
+	}
+}
+
+// MarshalYAML serializes the environment content with its sensitive values masked
+func (r Environment) MarshalYAML() (interface{}, error) {
+	// The content type doesn't implement yaml.Marshaler
+	type content Environment
+	b, err := yaml.Marshal(content(r))
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(b, &tree); err != nil {
+		return nil, err
+	}
+	return r.redactor().tree("", tree), nil
+}
+
+// redactor returns the redactor of the environment, masking nothing if the
+// sensitive values must be shown.
+//
+// The values located by the sensitive paths are collected over all the leaves
+// of the environment, the redactor must then be built once per export,
+// validation... and passed down.
+func (r Environment) redactor() redactor {
+	res := r.baseRedactor()
+	if res.disabled() {
+		return res
+	}
+	values := make([]string, 0)
+	for path, v := range r.leaves() {
+		if s, ok := v.(string); ok && res.sensitivePath(path) {
+			values = append(values, s)
+		}
+	}
+	return res.withValues(values...)
+}
+
+// baseRedactor returns the redactor masking the sensitive paths of the
+// environment and the values added as sensitive, without collecting the
+// values located by the sensitive paths.
+//
+// It is cheap to build but, used on a part of the environment, it doesn't mask
+// the values of the sensitive paths copied somewhere else; this is left to the
+// redactor of the whole environment.
+func (r Environment) baseRedactor() redactor {
+	if r.showSensitive {
+		return redactor{}
+	}
+	return redactor{enabled: true, paths: r.sensitive}.withValues(r.sensitiveValues...)
+}
+
+// withValues returns a copy of the redactor also masking the given values,
+// the ones too short to be masked wherever they appear being ignored
+func (r redactor) withValues(values ...string) redactor {
+	res := r
+	res.values = append([]string{}, r.values...)
+	for _, v := range values {
+		if len(v) >= minSensitiveValueLength && !contains(res.values, v) {
+			res.values = append(res.values, v)
+		}
+	}
+	sort.Slice(res.values, func(i, j int) bool {
+		if len(res.values[i]) != len(res.values[j]) {
+			return len(res.values[i]) > len(res.values[j])
+		}
+		return res.values[i] < res.values[j]
+	})
+	return res
+}
+
+// merge returns a redactor masking the content masked by both redactors
+func (r redactor) merge(o redactor) redactor {
+	res := redactor{
+		enabled: r.enabled || o.enabled,
+		paths:   append(append([]string{}, r.paths...), o.paths...),
+		values:  append(append([]string{}, r.values...), o.values...),
+	}
+	sort.SliceStable(res.values, func(i, j int) bool {
+		return len(res.values[i]) > len(res.values[j])
+	})
+	return res
+}
+
+// disabled returns true if the redactor doesn't mask anything
+func (r redactor) disabled() bool {
+	return !r.enabled
+}
+
+// sensitivePath returns true if the value located by the given path is sensitive
+func (r redactor) sensitivePath(path string) bool {
+	for _, p := range r.paths {
+		if pathMatches(p, path) {
+			return true
+		}
+	}
+	segments := strings.Split(strings.Replace(path, "[", ".[", -1), ".")
+	for i, s := range segments {
+		// The names of the environment elements are not sensitive
+		if i == 1 && (segments[0] == "providers" || segments[0] == "nodes" || segments[0] == "stacks" || segments[0] == "tasks") {
+			continue
+		}
+		if i == 2 && segments[0] == "ekara" {
+			continue
+		}
+		if contains(sensitiveContainers, strings.ToLower(s)) || sensitiveKey(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sensitiveKey returns true if the key denotes a sensitive value
+func sensitiveKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, p := range sensitiveKeyPatterns {
+		if strings.Contains(k, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathMatches returns true if the path matches the pattern or is located
+// under it, the "*" segments of the pattern matching any segment
+func pathMatches(pattern string, path string) bool {
+	ps := strings.Split(pattern, ".")
+	s := strings.Split(path, ".")
+	if len(s) < len(ps) {
+		return false
+	}
+	for i, p := range ps {
+		if p != "*" && p != s[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// value returns the value, located by the given path, masked if sensitive
+func (r redactor) value(path string, v interface{}) interface{} {
+	if r.disabled() || v == nil {
+		return v
+	}
+	switch val := v.(type) {
+	case map[interface{}]interface{}, map[string]interface{}, Parameters, []interface{}, []string, EnvVars:
+		return r.tree(path, val)
+	case string:
+		if r.sensitivePath(path) {
+			return secretMask
+		}
+		segments := strings.Split(path, ".")
+		if contains(proxyKeys, segments[len(segments)-1]) {
+			val = maskURLPassword(val)
+		}
+		return r.text(val)
+	}
+	if r.sensitivePath(path) {
+		return secretMask
+	}
+	return v
+}
+
+// tree returns a copy of the given tree of maps and slices with its sensitive
+// values masked, the path being the location of the tree
+func (r redactor) tree(path string, v interface{}) interface{} {
+	if r.disabled() {
+		return v
+	}
+	child := func(k string) string {
+		if path == "" {
+			return k
+		}
+		return path + "." + k
+	}
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(val))
+		for k, e := range val {
+			res[k] = r.value(child(fmt.Sprintf("%v", k)), e)
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			res[k] = r.value(child(k), e)
+		}
+		return res
+	case Parameters:
+		return Parameters(r.tree(path, map[string]interface{}(val)).(map[string]interface{}))
+	case EnvVars:
+		res := make(EnvVars, len(val))
+		for k, e := range val {
+			res[k] = r.value(child(k), e).(string)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, 0, len(val))
+		for i, e := range val {
+			res = append(res, r.value(fmt.Sprintf("%s[%d]", path, i), e))
+		}
+		return res
+	case []string:
+		res := make([]string, 0, len(val))
+		for i, e := range val {
+			res = append(res, r.value(fmt.Sprintf("%s[%d]", path, i), e).(string))
+		}
+		return res
+	}
+	return r.value(path, v)
+}
+
+// text returns the text with all the sensitive values masked
+func (r redactor) text(s string) string {
+	for _, v := range r.values {
+		s = strings.Replace(s, v, secretMask, -1)
+	}
+	return s
+}
+
+// proxy returns the proxy with the credentials of its urls masked
+func (r redactor) proxy(p Proxy) Proxy {
+	if r.disabled() {
+		return p
+	}
+	return Proxy{
+		Http:    r.text(maskURLPassword(p.Http)),
+		Https:   r.text(maskURLPassword(p.Https)),
+		NoProxy: p.NoProxy,
+	}
+}
+
+// maskURLPassword returns the url with the password of its user info masked
+func maskURLPassword(u string) string {
+	i := strings.Index(u, "://")
+	if i < 0 {
+		return u
+	}
+	rest := u[i+3:]
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	at := strings.LastIndex(rest[:end], "@")
+	if at < 0 {
+		return u
+	}
+	colon := strings.Index(rest[:at], ":")
+	if colon < 0 {
+		return u
+	}
+	return u[:i+3] + rest[:colon+1] + secretMask + rest[at:]
+}
+
+// errors returns the validation errors with the sensitive values masked into
+// their messages and their origins
+func (r redactor) errors(vErrs ValidationErrors) ValidationErrors {
+	if r.disabled() {
+		return vErrs
+	}
+	res := ValidationErrors{}
+	for _, e := range vErrs.Errors {
+		e.Message = r.text(e.Message)
+		if len(e.Origins) > 0 {
+			e.Origins = r.origins(e.Origins)
+		}
+		res.Errors = append(res.Errors, e)
+	}
+	return res
+}
+
+// origins returns the origins with their sensitive values masked
+func (r redactor) origins(origins []Origin) []Origin {
+	res := make([]Origin, 0, len(origins))
+	for _, o := range origins {
+		o.Value = r.value(o.Location.Path, o.Value)
+		res = append(res, o)
+	}
+	return res
+}
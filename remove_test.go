@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveInheritedElements(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/remove/parent.yaml", "./testdata/yaml/remove/main.yaml")
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"node1"}, env.NodeSets.names())
+	assert.Equal(t, []string{"app"}, env.Stacks.names())
+	// The removal of an element which doesn't exist is ignored
+	assert.Equal(t, []string{"cleanup"}, env.Tasks.names())
+
+	assert.Equal(t, 0, len(env.Hooks.Deploy.Before))
+	if assert.Equal(t, 1, len(env.Hooks.Deploy.After)) {
+		assert.Equal(t, "cleanup", env.Hooks.Deploy.After[0].ref)
+	}
+
+	assert.True(t, env.isRemoved("stacks", "monitoring"))
+	assert.False(t, env.isRemoved("tasks", "unknown"))
+}
+
+func TestRemoveDanglingReferences(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/remove/parent.yaml", "./testdata/yaml/remove/main.yaml")
+	assert.Nil(t, err)
+
+	vErrs := env.Validate()
+	assert.True(t, vErrs.contains(Error, "reference to removed stack dependency: monitoring", "stacks.app.depends_on.monitoring"))
+	assert.True(t, vErrs.contains(Error, "reference to removed task: backup", "hooks.create.after"))
+}
@@ -93,12 +93,15 @@ func resolveRepositoryInfo(base Base, repo string) (cUrl EkURL, e error) {
 		}
 	}
 
-	// If it's HTTP(S), assume it's GIT and add the suffix
-	if (cUrl.UpperScheme() == SchemeHttp || cUrl.UpperScheme() == SchemeHttps || cUrl.UpperScheme() == SchemeGits) && !hasSuffixIgnoringCase(cUrl.Path(), GitExtension) {
+	// If it's HTTP(S), assume it's GIT and add the suffix, unless the url
+	// already ends with it
+	if cUrl.UpperScheme() == SchemeHttp || cUrl.UpperScheme() == SchemeHttps || cUrl.UpperScheme() == SchemeGits {
 		if hasSuffixIgnoringCase(cUrl.Path(), "/") {
 			cUrl.RemovePathSuffix("/")
 		}
-		cUrl.AddPathSuffix(GitExtension)
+		if !hasSuffixIgnoringCase(cUrl.Path(), GitExtension) {
+			cUrl.AddPathSuffix(GitExtension)
+		}
 	}
 	return
 }
@@ -67,6 +67,17 @@ func TestCreateExplicitRepository(t *testing.T) {
 	}
 }
 
+func TestCreateExplicitRepositoryWithExtension(t *testing.T) {
+	repo := "https://github.my_company.com/organisation/repo.git"
+	b, e := CreateBase("")
+	assert.Nil(t, e)
+	r, e := CreateRepository(b, repo, "master", "")
+	if assert.Nil(t, e) {
+		// The extension is not added twice, allowing to parse an exported descriptor
+		assert.Equal(t, r.Url.String(), repo)
+	}
+}
+
 func TestCreateBasedSimpleRepository(t *testing.T) {
 	base := "http://github.my_company.com"
 	repo := "organisation/repo"
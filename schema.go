@@ -0,0 +1,102 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+//go:generate go run ./generate/schema/schema.go
+
+const (
+	// DescriptorSchemaID specifies the identifier of the JSON Schema describing the
+	// environment descriptor
+	DescriptorSchemaID = "https://github.com/ekara-platform/model/schema/ekara.schema.json"
+
+	// DescriptorSchemaFile specifies the location, into this repository, of the
+	// generated JSON Schema describing the environment descriptor
+	DescriptorSchemaFile = "schema/ekara.schema.json"
+)
+
+// DescriptorSchema returns the JSON Schema describing the grammar of the
+// environment descriptor.
+//
+// The schema is generated from the structures used to parse the descriptor,
+// it describes the descriptor once templated. Keys not belonging to the grammar are
+// rejected, with the exception of the free content of the parameters,
+// variables and authentication blocks.
+func DescriptorSchema() ([]byte, error) {
+	definitions := make(map[string]interface{})
+	schema := jsonSchemaOf(reflect.TypeOf(yamlEnvironment{}), definitions, true)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = DescriptorSchemaID
+	schema["title"] = "Ekara environment descriptor"
+	schema["definitions"] = definitions
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return b, err
+	}
+	return append(b, '\n'), nil
+}
+
+// jsonSchemaOf returns the JSON Schema of the given yaml type.
+//
+// Named structures are stored into the definitions and referenced, unless
+// they must be inlined.
+func jsonSchemaOf(t reflect.Type, definitions map[string]interface{}, inline bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if name := jsonSchemaName(t); !inline && name != "" {
+			if _, ok := definitions[name]; !ok {
+				// Register the name before going deeper to support recursive types
+				definitions[name] = nil
+				definitions[name] = jsonSchemaOf(t, definitions, true)
+			}
+			return map[string]interface{}{"$ref": "#/definitions/" + name}
+		}
+		properties := make(map[string]interface{})
+		for _, f := range yamlFields(t) {
+			properties[f.name] = jsonSchemaOf(f.fType, definitions, false)
+		}
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": jsonSchemaOf(t.Elem(), definitions, false),
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  []string{"array", "null"},
+			"items": jsonSchemaOf(t.Elem(), definitions, false),
+		}
+	case reflect.String:
+		// Like the yaml parser, accept any scalar where a string is expected
+		return map[string]interface{}{"type": []string{"string", "number", "boolean"}}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	// Free content
+	return map[string]interface{}{}
+}
+
+// jsonSchemaName returns the name of the definition of a named yaml structure,
+// "yamlTaskRef" will be defined as "taskRef"
+func jsonSchemaName(t reflect.Type) string {
+	name := strings.TrimPrefix(t.Name(), "yaml")
+	if name == "" {
+		return ""
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescriptorSchemaUpToDate(t *testing.T) {
+	b, e := DescriptorSchema()
+	assert.Nil(t, e)
+	committed, e := ioutil.ReadFile(DescriptorSchemaFile)
+	assert.Nil(t, e)
+	// If this fails the schema must be regenerated using "go generate"
+	assert.Equal(t, string(b), string(committed))
+}
+
+func TestDescriptorSchemaContent(t *testing.T) {
+	b, e := DescriptorSchema()
+	assert.Nil(t, e)
+	schema := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(b, &schema))
+
+	properties := schema["properties"].(map[string]interface{})
+	for _, k := range []string{"name", "qualifier", "description", "ekara", "vars", "tasks", "orchestrator", "providers", "nodes", "stacks", "hooks", "volumes"} {
+		assert.Contains(t, properties, k)
+	}
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	definitions := schema["definitions"].(map[string]interface{})
+	assert.Contains(t, definitions, "hook")
+	assert.Contains(t, definitions, "taskRef")
+	assert.Contains(t, definitions, "proxy")
+
+	// Nodes are referencing the node definition
+	nodes := properties["nodes"].(map[string]interface{})
+	assert.Equal(t, "#/definitions/node", nodes["additionalProperties"].(map[string]interface{})["$ref"])
+	node := definitions["node"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, "integer", node["instances"].(map[string]interface{})["type"])
+	assert.Contains(t, node, "labels")
+
+	// Parameters are free content
+	stacks := definitions["stack"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{}, stacks["params"].(map[string]interface{})["additionalProperties"])
+	assert.Contains(t, stacks, "depends_on")
+}
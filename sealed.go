@@ -0,0 +1,204 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type (
+	// sealedPath represents a path, declared into the "sealed:" section of a
+	// descriptor, which cannot be overridden by the customizing descriptors
+	sealedPath struct {
+		// pattern is the sealed path, like "providers.*.proxy", the "*"
+		// segments matching any segment
+		pattern string
+		// component is the id of the component which has sealed the path
+		component string
+		// location is the location of the declaration of the sealed path
+		location DescriptorLocation
+	}
+)
+
+// WarnOnSealedOverrides sets how the overrides of the values sealed by the
+// customized descriptors are handled.
+//
+// By default Customize fails, returning ValidationErrors located where the sealed
+// values are overridden. If warn is true the overrides are accepted and
+// reported as warnings by Validate.
+func (r *Environment) WarnOnSealedOverrides(warn bool) {
+	r.warnSealedOverrides = warn
+}
+
+// Sealed returns the paths sealed by the descriptors which have customized
+// the environment
+func (r *Environment) Sealed() []string {
+	res := make([]string, 0, len(r.sealed))
+	for _, s := range r.sealed {
+		if !contains(res, s.pattern) {
+			res = append(res, s.pattern)
+		}
+	}
+	return res
+}
+
+// createSealedPaths returns the paths sealed by a descriptor
+func createSealedPaths(location DescriptorLocation, patterns []string) []sealedPath {
+	res := make([]sealedPath, 0, len(patterns))
+	for i, p := range patterns {
+		res = append(res, sealedPath{pattern: p, location: location.appendIndex(i)})
+	}
+	return res
+}
+
+// seal keeps the paths sealed by the customizing component
+func (r *Environment) seal(from Component, with *Environment) {
+	for _, s := range with.sealed {
+		s.component = from.Id
+		r.sealed = append(r.sealed, s)
+	}
+}
+
+// sealedOverrides returns the overrides, by the customizing environment, of the
+// values sealed into the receiver.
+//
+// A sealed value is overridden if the customizing environment sets it to
+// another value, deletes it or removes the element holding it.
+func (r *Environment) sealedOverrides(from Component, with *Environment) ValidationErrors {
+	vErrs := ValidationErrors{}
+	if len(r.sealed) == 0 {
+		return vErrs
+	}
+	t := Error
+	if r.warnSealedOverrides {
+		t = Warning
+	}
+	report := func(path string, s sealedPath) {
+		vErrs.Errors = append(vErrs.Errors, ValidationError{
+			ErrorType: t,
+			Location:  with.locate(path),
+			Component: from.Id,
+			Origins:   []Origin{{Component: s.component, Location: s.location}},
+			Message:   fmt.Sprintf("%s is sealed by %s", path, s.component),
+		})
+	}
+
+	before := r.leaves()
+	withLeaves := with.customizingLeaves()
+	removals := with.removals()
+	paths := make([]string, 0, len(withLeaves))
+	// The customizing values indexed by their path without directives
+	values := make(map[string]interface{}, len(withLeaves))
+	for p, v := range withLeaves {
+		paths = append(paths, p)
+		if _, d := splitDirective(p[strings.LastIndex(p, ".")+1:]); d != DirectiveDelete {
+			values[withoutDirectives(p)] = v
+		}
+	}
+	sort.Strings(paths)
+	reported := make(map[string]bool)
+	for _, p := range paths {
+		if removed(p, removals) {
+			continue
+		}
+		path := withoutDirectives(p)
+		// The values deleted or replaced along with one of their parents
+		d, ok := overridingDirective(p)
+		if ok && reported[d] {
+			continue
+		}
+		if ok {
+			for _, s := range r.sealed {
+				if sealedChanged(s.pattern, withoutDirectives(d), before, values) {
+					report(d, s)
+					reported[d] = true
+					break
+				}
+			}
+			if reported[d] {
+				continue
+			}
+		}
+		for _, s := range r.sealed {
+			if pathMatches(s.pattern, path) && !reflect.DeepEqual(before[path], values[path]) {
+				report(p, s)
+				break
+			}
+		}
+	}
+
+	for _, p := range removals {
+		for _, s := range r.sealed {
+			if pathMatches(s.pattern, p) || pathMatches(p, s.pattern) {
+				report(p, s)
+				break
+			}
+		}
+	}
+	return vErrs
+}
+
+// overridingDirective returns the given path up to its first segment deleting
+// or replacing the inherited value, and true if there is such a segment
+func overridingDirective(path string) (string, bool) {
+	segments := strings.Split(path, ".")
+	for i, seg := range segments {
+		if _, d := splitDirective(seg); d == DirectiveDelete || d == DirectiveReplace {
+			return strings.Join(segments[:i+1], "."), true
+		}
+	}
+	return "", false
+}
+
+// sealedChanged returns true if one of the values sealed by the pattern and
+// located under the deleted or replaced path is changed by the customizing
+// values, the values not set being deleted
+func sealedChanged(pattern string, prefix string, before map[string]interface{}, values map[string]interface{}) bool {
+	for path, v := range before {
+		if (path == prefix || strings.HasPrefix(path, prefix+".")) && pathMatches(pattern, path) && !reflect.DeepEqual(v, values[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+// removals returns the paths of the elements flagged for removal, in alphabetical order
+func (r *Environment) removals() []string {
+	res := make([]string, 0)
+	for _, name := range r.NodeSets.names() {
+		if r.NodeSets[name].remove {
+			res = append(res, "nodes."+name)
+		}
+	}
+	for _, name := range r.Stacks.names() {
+		if r.Stacks[name].remove {
+			res = append(res, "stacks."+name)
+		}
+	}
+	for _, name := range r.Tasks.names() {
+		if r.Tasks[name].remove {
+			res = append(res, "tasks."+name)
+		}
+	}
+	return res
+}
+
+// removed returns true if the path is located under one of the removed elements
+func removed(path string, removals []string) bool {
+	for _, r := range removals {
+		if strings.HasPrefix(path, r+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutDirectives returns the path without the merge directives of its segments
+func withoutDirectives(path string) string {
+	segments := strings.Split(path, ".")
+	for i, s := range segments {
+		segments[i], _ = splitDirective(s)
+	}
+	return strings.Join(segments, ".")
+}
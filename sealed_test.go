@@ -0,0 +1,76 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSealedOverridesRejected(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/sealed/parent.yaml", "./testdata/yaml/sealed/main.yaml")
+	assert.Equal(t, []string{"orchestrator.component", "providers.*.proxy", "stacks.monitoring.params.security", "stacks.audit", "providers.*.params.limits.cpu"}, env.Sealed())
+	if assert.NotNil(t, err) {
+		vErrs, ok := err.(ValidationErrors)
+		if assert.True(t, ok) {
+			assert.Equal(t, 3, len(vErrs.Errors))
+			assert.True(t, vErrs.contains(Error, "providers.aws.proxy.http_proxy is sealed by parent", "providers.aws.proxy.http_proxy"))
+			assert.True(t, vErrs.contains(Error, "stacks.monitoring.params.security.tls is sealed by parent", "stacks.monitoring.params.security.tls"))
+			assert.True(t, vErrs.contains(Error, "stacks.audit is sealed by parent", "stacks.audit"))
+
+			e := vErrs.locate("providers.aws.proxy.http_proxy is sealed by parent")[0]
+			assert.Equal(t, MainComponentId, e.Component)
+			assert.Equal(t, "./testdata/yaml/sealed/main.yaml", e.Location.Descriptor)
+			assert.Equal(t, 16, e.Location.Line)
+			if assert.Equal(t, 1, len(e.Origins)) {
+				assert.Equal(t, "parent", e.Origins[0].Component)
+				assert.Equal(t, "./testdata/yaml/sealed/parent.yaml", e.Origins[0].Location.Descriptor)
+				assert.Equal(t, 13, e.Origins[0].Location.Line)
+			}
+		}
+	}
+	// The environment remains unchanged
+	assert.Equal(t, "http://proxy:3128", env.Providers["aws"].Proxy.Http)
+	assert.Equal(t, "eu-west-1", env.Providers["aws"].Parameters["region"])
+	assert.Contains(t, env.Stacks.names(), "audit")
+}
+
+func TestSealedOverridesWarned(t *testing.T) {
+	env, err := customizeWith(t, "./testdata/yaml/sealed/parent.yaml", "./testdata/yaml/sealed/main.yaml", func(env *Environment) {
+		env.WarnOnSealedOverrides(true)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"tls": false}, env.Stacks["monitoring"].Parameters["security"])
+	assert.NotContains(t, env.Stacks.names(), "audit")
+
+	vErrs := env.Validate()
+	assert.True(t, vErrs.contains(Warning, "providers.aws.proxy.http_proxy is sealed by parent", "providers.aws.proxy.http_proxy"))
+	assert.True(t, vErrs.contains(Warning, "stacks.monitoring.params.security.tls is sealed by parent", "stacks.monitoring.params.security.tls"))
+	assert.True(t, vErrs.contains(Warning, "stacks.audit is sealed by parent", "stacks.audit"))
+}
+
+func TestSealedOverridesDirectives(t *testing.T) {
+	customize := func(path string) error {
+		_, err := customizeWith(t, "./testdata/yaml/sealed/parent.yaml", path)
+		return err
+	}
+
+	// Deleting a parent of a sealed value
+	err := customize("./testdata/yaml/sealed/delete.yaml")
+	if assert.NotNil(t, err) {
+		vErrs := err.(ValidationErrors)
+		assert.Equal(t, 1, len(vErrs.Errors))
+		assert.True(t, vErrs.contains(Error, "providers.aws.params.limits!delete is sealed by parent", "providers.aws.params.limits!delete"))
+		assert.Equal(t, 13, vErrs.Errors[0].Location.Line)
+	}
+
+	// Replacing a parent of a sealed value without it
+	err = customize("./testdata/yaml/sealed/replace.yaml")
+	if assert.NotNil(t, err) {
+		vErrs := err.(ValidationErrors)
+		assert.Equal(t, 1, len(vErrs.Errors))
+		assert.True(t, vErrs.contains(Error, "providers.aws.params.limits!replace is sealed by parent", "providers.aws.params.limits!replace"))
+	}
+
+	// Replacing a parent of a sealed value keeping it
+	assert.Nil(t, customize("./testdata/yaml/sealed/kept.yaml"))
+}
@@ -0,0 +1,406 @@
+package model
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SecretParameterPrefix is the prefix of the parameter values referencing
+	// a secret, for example "secret:env://AWS_SECRET_ACCESS_KEY"
+	SecretParameterPrefix = "secret:"
+
+	// secretMask replaces the secret values when masked
+	secretMask = "******"
+)
+
+type (
+	// SecretResolver represents a backend able to resolve secrets
+	SecretResolver interface {
+		// Resolve returns the value of the secret located by the given path
+		Resolve(path string) (string, error)
+	}
+
+	// FileSecretResolver resolves the secrets stored into the files of a
+	// directory, the path of a secret being the path of its file into the
+	// directory
+	FileSecretResolver struct {
+		// Dir is the directory holding the secret files
+		Dir string
+	}
+
+	// EnvSecretResolver resolves the secrets stored into environment
+	// variables, the path of a secret being the name of its variable
+	EnvSecretResolver struct{}
+
+	// EncryptedFileSecretResolver resolves the secrets stored encrypted into
+	// the files of a directory, the path of a secret being the path of its
+	// file into the directory.
+	//
+	// The files must contain the secret encrypted with AES-GCM, as returned by
+	// EncryptSecret.
+	EncryptedFileSecretResolver struct {
+		// Dir is the directory holding the secret files
+		Dir string
+		// Key is the AES key, of 16, 24 or 32 bytes
+		Key []byte
+	}
+
+	// secrets keeps track of the resolvers and of the resolved secrets of
+	// a template context and of all its clones
+	secrets struct {
+		sync.Mutex
+		resolvers map[string]SecretResolver
+		values    map[string]struct{}
+	}
+)
+
+func newSecrets() *secrets {
+	return &secrets{
+		resolvers: make(map[string]SecretResolver),
+		values:    make(map[string]struct{}),
+	}
+}
+
+//Resolve returns the content of the file, without its trailing new line
+func (r FileSecretResolver) Resolve(path string) (string, error) {
+	b, err := readSecretFile(r.Dir, path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+//Resolve returns the content of the environment variable
+func (r EnvSecretResolver) Resolve(path string) (string, error) {
+	v, ok := os.LookupEnv(path)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not defined", path)
+	}
+	return v, nil
+}
+
+//Resolve returns the decrypted content of the file
+func (r EncryptedFileSecretResolver) Resolve(path string) (string, error) {
+	b, err := readSecretFile(r.Dir, path)
+	if err != nil {
+		return "", err
+	}
+	return DecryptSecret(r.Key, strings.TrimSpace(string(b)))
+}
+
+// readSecretFile reads the file located by the path into the directory,
+// refusing the paths going out of the directory
+func readSecretFile(dir string, path string) ([]byte, error) {
+	clean := filepath.Clean("/" + path)
+	if clean != "/"+path {
+		return nil, fmt.Errorf("invalid secret path: %s", path)
+	}
+	return ioutil.ReadFile(filepath.Join(dir, clean))
+}
+
+// EncryptSecret encrypts the secret with AES-GCM, the result is base64
+// encoded and starts with the random nonce used for the encryption.
+func EncryptSecret(key []byte, secret string) (string, error) {
+	gcm, err := secretCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// DecryptSecret decrypts a secret encrypted by EncryptSecret
+func DecryptSecret(key []byte, encrypted string) (string, error) {
+	gcm, err := secretCipher(key)
+	if err != nil {
+		return "", err
+	}
+	b, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	if len(b) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted secret")
+	}
+	res, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+func secretCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// RegisterSecretResolver registers the resolver of the secrets referenced
+// with the given scheme, for example "env" for "env://AWS_SECRET_ACCESS_KEY"
+func (cc *TemplateContext) RegisterSecretResolver(scheme string, r SecretResolver) {
+	cc.secretsHolder().Lock()
+	defer cc.secrets.Unlock()
+	cc.secrets.resolvers[scheme] = r
+}
+
+// ResolveSecret returns the value of the secret referenced like "scheme://path".
+//
+// The resolved value is kept in order to be masked.
+func (cc *TemplateContext) ResolveSecret(ref string) (string, error) {
+	i := strings.Index(ref, "://")
+	if i <= 0 {
+		return "", fmt.Errorf("invalid secret reference: %s", ref)
+	}
+	s := cc.secretsHolder()
+	s.Lock()
+	r, ok := s.resolvers[ref[:i]]
+	s.Unlock()
+	if !ok {
+		return "", fmt.Errorf("no secret resolver registered for: %s", ref[:i])
+	}
+	v, err := r.Resolve(ref[i+3:])
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve secret %s: %s", ref, err.Error())
+	}
+	s.add(v)
+	return v, nil
+}
+
+// add keeps the secret value in order to be masked
+func (s *secrets) add(v string) {
+	if v == "" {
+		return
+	}
+	s.Lock()
+	s.values[v] = struct{}{}
+	s.Unlock()
+}
+
+// SecretValues returns all the secret values resolved using the context
+// or one of its clones
+func (cc *TemplateContext) SecretValues() []string {
+	s := cc.secretsHolder()
+	s.Lock()
+	defer s.Unlock()
+	res := make([]string, 0, len(s.values))
+	for v := range s.values {
+		res = append(res, v)
+	}
+	// The longest first to mask the secrets containing other ones
+	sort.Slice(res, func(i, j int) bool {
+		if len(res[i]) != len(res[j]) {
+			return len(res[i]) > len(res[j])
+		}
+		return res[i] < res[j]
+	})
+	return res
+}
+
+// MaskSecrets returns the given content with all the resolved secret values masked
+func (cc *TemplateContext) MaskSecrets(content string) string {
+	for _, v := range cc.SecretValues() {
+		content = strings.Replace(content, v, secretMask, -1)
+	}
+	return content
+}
+
+// secretsHolder returns the secrets of the context, creating them if required
+func (cc *TemplateContext) secretsHolder() *secrets {
+	if cc.secrets == nil {
+		cc.secrets = newSecrets()
+	}
+	return cc.secrets
+}
+
+// ResolveSecrets returns a copy of the parameters where all the values
+// referencing a secret, like "secret:scheme://path", are replaced by the
+// value of the secret.
+func (r Parameters) ResolveSecrets(ctx *TemplateContext) (Parameters, error) {
+	res, err := resolveSecretValue(r, ctx)
+	if err != nil {
+		return r, err
+	}
+	return res.(map[string]interface{}), nil
+}
+
+func resolveSecretValue(v interface{}, ctx *TemplateContext) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if ref, ok := secretReference(val); ok {
+			return ctx.ResolveSecret(ref)
+		}
+	case Parameters:
+		return resolveSecretValue(map[string]interface{}(val), ctx)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			r, err := resolveSecretValue(e, ctx)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = r
+		}
+		return res, nil
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(val))
+		for k, e := range val {
+			r, err := resolveSecretValue(e, ctx)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = r
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, 0, len(val))
+		for _, e := range val {
+			r, err := resolveSecretValue(e, ctx)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, r)
+		}
+		return res, nil
+	}
+	return v, nil
+}
+
+// secretReference returns the reference of the secret, like "scheme://path",
+// if the given value looks like "secret:scheme://path". The other values, even
+// starting with "secret:", are not references.
+func secretReference(s string) (string, bool) {
+	if !strings.HasPrefix(s, SecretParameterPrefix) {
+		return "", false
+	}
+	ref := strings.TrimPrefix(s, SecretParameterPrefix)
+	i := strings.Index(ref, "://")
+	if i <= 0 {
+		return "", false
+	}
+	for j, c := range ref[:i] {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case j > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return "", false
+		}
+	}
+	return ref, true
+}
+
+// resolveDescriptorSecrets returns the templated descriptor content with the
+// values referencing a secret, like "secret:scheme://path", replaced by the
+// value of the secret.
+//
+// The secrets are resolved into the vars and into the parameters, the
+// environment variables and the authentication parameters of all the elements
+// of the descriptor. The content is
+// returned untouched if it doesn't reference any secret.
+func resolveDescriptorSecrets(content []byte, context *TemplateContext) ([]byte, error) {
+	if !bytes.Contains(content, []byte(SecretParameterPrefix)) {
+		return content, nil
+	}
+	return transformDescriptorValues(content, func(path string, s string) (interface{}, bool, error) {
+		ref, ok := secretReference(s)
+		if !ok {
+			return s, false, nil
+		}
+		v, err := context.ResolveSecret(ref)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %s", path, err.Error())
+		}
+		return v, true, nil
+	})
+}
+
+// transformDescriptorValues applies the given transformation on the string
+// values of the vars and of the parameters, environment variables and
+// authentication parameters of the descriptor content. The content is returned untouched if the
+// transformation hasn't changed anything.
+func transformDescriptorValues(content []byte, f func(path string, s string) (interface{}, bool, error)) ([]byte, error) {
+	tree := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(content, &tree); err != nil {
+		return content, err
+	}
+	changed := false
+	res, err := transformDescriptorValue(nil, tree, false, f, &changed)
+	if err != nil || !changed {
+		return content, err
+	}
+	return yaml.Marshal(res)
+}
+
+// parameterContainers are the keys holding parameters, environment variables
+// or authentication parameters
+var parameterContainers = []string{"params", "env", "auth"}
+
+func transformDescriptorValue(path []string, v interface{}, within bool, f func(path string, s string) (interface{}, bool, error), changed *bool) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if !within {
+			return v, nil
+		}
+		res, ok, err := f(strings.Join(path, "."), val)
+		if err != nil {
+			return nil, err
+		}
+		*changed = *changed || ok
+		return res, nil
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(val))
+		keys := make(map[string]interface{}, len(val))
+		for k := range val {
+			keys[fmt.Sprintf("%v", k)] = k
+		}
+		// The keys are walked in order to report the errors deterministically
+		for _, key := range sortedKeys(keys) {
+			k := keys[key]
+			e := val[k]
+			// The names of the elements are not keys holding parameters
+			name := len(path) == 1 && (path[0] == "providers" || path[0] == "nodes" || path[0] == "stacks" || path[0] == "tasks") ||
+				len(path) == 2 && path[0] == "ekara" && path[1] == "components"
+			in := within || (len(path) == 0 && key == "vars") || (!name && contains(parameterContainers, key))
+			r, err := transformDescriptorValue(append(append([]string{}, path...), key), e, in, f, changed)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = r
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, 0, len(val))
+		for i, e := range val {
+			p := append([]string{}, path...)
+			if len(p) > 0 {
+				p[len(p)-1] = fmt.Sprintf("%s[%d]", p[len(p)-1], i)
+			}
+			r, err := transformDescriptorValue(p, e, within, f, changed)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, r)
+		}
+		return res, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,196 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func secretTestContext(t *testing.T) (*TemplateContext, string) {
+	dir, err := ioutil.TempDir("", "secrets")
+	assert.Nil(t, err)
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "password"), []byte("file_secret\n"), 0600))
+	enc, err := EncryptSecret(key, "encrypted_secret")
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte(enc), 0600))
+	os.Setenv("EKARA_TEST_SECRET", "env_secret")
+
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{}))
+	ctx.RegisterSecretResolver("file", FileSecretResolver{Dir: dir})
+	ctx.RegisterSecretResolver("env", EnvSecretResolver{})
+	ctx.RegisterSecretResolver("enc", EncryptedFileSecretResolver{Dir: dir, Key: key})
+	return ctx, dir
+}
+
+func TestResolveSecret(t *testing.T) {
+	ctx, dir := secretTestContext(t)
+	defer os.RemoveAll(dir)
+
+	v, err := ctx.ResolveSecret("file://password")
+	assert.Nil(t, err)
+	assert.Equal(t, "file_secret", v)
+
+	v, err = ctx.ResolveSecret("env://EKARA_TEST_SECRET")
+	assert.Nil(t, err)
+	assert.Equal(t, "env_secret", v)
+
+	v, err = ctx.ResolveSecret("enc://token")
+	assert.Nil(t, err)
+	assert.Equal(t, "encrypted_secret", v)
+
+	_, err = ctx.ResolveSecret("unknown://password")
+	assert.NotNil(t, err)
+	_, err = ctx.ResolveSecret("password")
+	assert.NotNil(t, err)
+	_, err = ctx.ResolveSecret("file://../password")
+	assert.NotNil(t, err)
+	_, err = ctx.ResolveSecret("env://EKARA_TEST_UNDEFINED_SECRET")
+	assert.NotNil(t, err)
+
+	// The resolved secrets are tracked, also by the clones
+	c, err := CloneTemplateContext(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"encrypted_secret", "file_secret", "env_secret"}, c.SecretValues())
+	assert.Equal(t, "user: bob, password: ******", c.MaskSecrets("user: bob, password: file_secret"))
+}
+
+func TestDecryptSecretWrongKey(t *testing.T) {
+	enc, err := EncryptSecret([]byte("0123456789abcdef"), "secret")
+	assert.Nil(t, err)
+	_, err = DecryptSecret([]byte("fedcba9876543210"), enc)
+	assert.NotNil(t, err)
+	_, err = EncryptSecret([]byte("short"), "secret")
+	assert.NotNil(t, err)
+}
+
+func TestSecretIntoDescriptor(t *testing.T) {
+	ctx, dir := secretTestContext(t)
+	defer os.RemoveAll(dir)
+
+	content := []byte(`name: secret
+vars:
+  password: secret:file://password
+providers:
+  aws:
+    component: aws
+    params:
+      password: "{{ .Vars.password }}"
+      token: '{{ secret "enc://token" }}'
+`)
+	yamlEnv, err := ParseYamlDescriptorBytes("secret.yaml", content, ctx)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "file_secret", yamlEnv.Providers["aws"].Params["password"])
+		assert.Equal(t, "encrypted_secret", yamlEnv.Providers["aws"].Params["token"])
+	}
+	assert.Equal(t, 2, len(ctx.SecretValues()))
+}
+
+func TestSecretsIntoParameters(t *testing.T) {
+	ctx, dir := secretTestContext(t)
+	defer os.RemoveAll(dir)
+
+	content := []byte(`name: secret
+description: "secret:file://password"
+ekara:
+  parent:
+    repository: parent
+    auth:
+      token: secret:enc://token
+  components:
+    auth:
+      repository: aws
+      auth:
+        password: secret:file://password
+vars:
+  db:
+    password: secret:file://password
+orchestrator:
+  env:
+    TOKEN: secret:enc://token
+providers:
+  params:
+    component: aws
+    params:
+      keys: [secret:env://EKARA_TEST_SECRET]
+nodes:
+  node1:
+    provider:
+      name: params
+      params:
+        password: secret:file://password
+stacks:
+  stack1:
+    component: stack1
+    env:
+      PASSWORD: secret:env://EKARA_TEST_SECRET
+tasks:
+  task1:
+    component: task1
+    params:
+      token: secret:enc://token
+`)
+	yamlEnv, err := ParseYamlDescriptorBytes("secret.yaml", content, ctx)
+	if assert.Nil(t, err) {
+		password, err := CreateParameters(yamlEnv.Vars).String("db.password")
+		assert.Nil(t, err)
+		assert.Equal(t, "file_secret", password)
+		assert.Equal(t, "encrypted_secret", yamlEnv.Orchestrator.Env["TOKEN"])
+		assert.Equal(t, []interface{}{"env_secret"}, yamlEnv.Providers["params"].Params["keys"])
+		assert.Equal(t, "file_secret", yamlEnv.Nodes["node1"].Provider.Params["password"])
+		assert.Equal(t, "env_secret", yamlEnv.Stacks["stack1"].Env["PASSWORD"])
+		assert.Equal(t, "encrypted_secret", yamlEnv.Tasks["task1"].Params["token"])
+		assert.Equal(t, "encrypted_secret", yamlEnv.Ekara.Parent.Auth["token"])
+		assert.Equal(t, "file_secret", yamlEnv.Ekara.Components["auth"].Auth["password"])
+		// Only the parameters and the environment variables reference secrets
+		assert.Equal(t, "secret:file://password", yamlEnv.Description)
+	}
+
+	yamlEnv, err = ParseYamlDescriptorBytes("secret.yaml", []byte("stacks:\n  stack1:\n    params:\n      hint: 'secret: ask the team'\n"), ctx)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "secret: ask the team", yamlEnv.Stacks["stack1"].Params["hint"])
+	}
+
+	_, err = ParseYamlDescriptorBytes("secret.yaml", []byte("stacks:\n  stack1:\n    params:\n      password: secret:unknown://password\n"), ctx)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "stacks.stack1.params.password: no secret resolver registered for: unknown")
+	}
+}
+
+func TestParametersResolveSecrets(t *testing.T) {
+	ctx, dir := secretTestContext(t)
+	defer os.RemoveAll(dir)
+
+	p := CreateParameters(map[string]interface{}{
+		"plain": "value",
+		"env":   "secret:env://EKARA_TEST_SECRET",
+		"nested": map[interface{}]interface{}{
+			"list": []interface{}{"secret:file://password", 1},
+		},
+	})
+	res, err := p.ResolveSecrets(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "value", res["plain"])
+	assert.Equal(t, "env_secret", res["env"])
+	assert.Equal(t, []interface{}{"file_secret", 1}, res["nested"].(map[string]interface{})["list"])
+	// The original parameters remain unchanged
+	assert.Equal(t, "secret:env://EKARA_TEST_SECRET", p["env"])
+
+	// Only the values like "secret:scheme://" are references
+	p = CreateParameters(map[string]interface{}{
+		"plain":  "secret:not a reference",
+		"path":   "secret:/path",
+		"scheme": "secret:1a://value",
+	})
+	res, err = p.ResolveSecrets(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, p, res)
+
+	p["missing"] = "secret:unknown://value"
+	_, err = p.ResolveSecrets(ctx)
+	assert.NotNil(t, err)
+}
@@ -10,6 +10,8 @@ type (
 	Stack struct {
 		// The component containing the stack
 		cRef componentRef
+		// The location of the stack into the descriptor
+		location DescriptorLocation
 		// The name of the stack
 		Name string
 		//DependsOn specifies the stack references on which this one depends
@@ -24,6 +26,8 @@ type (
 		Copies Copies `yaml:",omitempty"`
 		// The custom stack deployment playbook
 		Playbook string
+		// Indicates if the inherited stack must be removed
+		remove bool
 	}
 
 	//StackRef defines a dependency a on stack which must be previously processed
@@ -88,6 +92,13 @@ func (r Stacks) customize(env *Environment, with Stacks) (Stacks, error) {
 	}
 
 	for id, s := range with {
+		if s.remove {
+			if _, ok := res[id]; ok {
+				delete(res, id)
+				env.markRemoved("stacks", id)
+			}
+			continue
+		}
 		if stack, ok := res[id]; ok {
 			sm := &stack
 			if err := sm.customize(s); err != nil {
@@ -120,8 +131,9 @@ func createStacks(env *Environment, holder string, location DescriptorLocation,
 		}
 
 		s := Stack{
-			Name: name,
-			cRef: createComponentRef(env, stackLocation.appendPath("component"), yC, false),
+			Name:     name,
+			location: stackLocation,
+			cRef:     createComponentRef(env, stackLocation.appendPath("component"), yC, false),
 			Hooks: StackHook{
 				Deploy: dHook},
 			Parameters: params,
@@ -129,6 +141,7 @@ func createStacks(env *Environment, holder string, location DescriptorLocation,
 			DependsOn:  createDependencies(env, stackLocation.appendPath("depends_on"), name, yamlStack.DependsOn),
 			Copies:     createCopies(env, stackLocation.appendPath("volume_copies"), yamlStack.Copies),
 			Playbook:   yamlStack.Playbook,
+			remove:     yamlStack.Remove,
 		}
 		res[name] = s
 		//env.Ekara.tagUsedComponent(res[name])
@@ -187,6 +200,7 @@ func (s StackRef) validationDetails() refValidationDetails {
 		Mandatory: true,
 		Location:  s.location,
 		Repo:      result,
+		Removed:   s.env.isRemoved("stacks", s.ref),
 	}
 }
 
@@ -209,3 +223,8 @@ func (s StackRef) Resolve() (Stack, error) {
 	task := s.env.Stacks[s.ref]
 	return task, nil
 }
+
+// names returns the names of the stacks in alphabetical order
+func (r Stacks) names() []string {
+	return sortedKeys(r)
+}
@@ -23,6 +23,8 @@ type (
 		EnvVars EnvVars `yaml:",omitempty"`
 		//The hooks linked to the task lifecycle events
 		Hooks TaskHook `yaml:",omitempty"`
+		// Indicates if the inherited task must be removed
+		remove bool
 	}
 
 	//Tasks represent all the tasks of an environment
@@ -98,6 +100,7 @@ func createTasks(env *Environment, location DescriptorLocation, yamlEnv *yamlEnv
 			Hooks: TaskHook{
 				Execute: eHook,
 			},
+			remove: yamlTask.Remove,
 		}
 		//env.Ekara.tagUsedComponent(res[name])
 	}
@@ -112,6 +115,13 @@ func (r Tasks) customize(env *Environment, with Tasks) (Tasks, error) {
 	}
 
 	for id, t := range with {
+		if t.remove {
+			if _, ok := work[id]; ok {
+				delete(work, id)
+				env.markRemoved("tasks", id)
+			}
+			continue
+		}
 		if task, ok := work[id]; ok {
 			if err := task.customize(*t); err != nil {
 				return work, err
@@ -141,3 +151,8 @@ func (r Task) Component() (Component, error) {
 func (r Task) ComponentName() string {
 	return r.cRef.ref
 }
+
+// names returns the names of the tasks in alphabetical order
+func (r Tasks) names() []string {
+	return sortedKeys(r)
+}
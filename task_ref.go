@@ -15,6 +15,8 @@ type (
 		env          *Environment
 		location     DescriptorLocation
 		mandatory    bool
+		// Indicates if the inherited references to the task must be removed
+		remove bool
 	}
 )
 
@@ -30,6 +32,7 @@ func (r TaskRef) validationDetails() refValidationDetails {
 		Mandatory: r.mandatory,
 		Location:  r.location,
 		Repo:      result,
+		Removed:   r.env.isRemoved("tasks", r.ref),
 	}
 }
 
@@ -73,6 +76,7 @@ func createTaskRef(env *Environment, location DescriptorLocation, tRef yamlTaskR
 		envVars:      createEnvVars(tRef.Env),
 		location:     location,
 		mandatory:    true,
+		remove:       tRef.Remove,
 	}, nil
 }
 
@@ -2,15 +2,34 @@ package model
 
 import (
 	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
 )
 
-//ApplyTemplate apply the parameters on the template represented by the descriptor content
+// missingKeyError matches the errors returned by a strict templating when a
+// variable is missing, for example:
+//
+//	template: name:3:14: executing "name" at <.Vars.typo>: map has no entry for key "typo"
+var missingKeyError = regexp.MustCompile(`:(\d+):(\d+): executing ".*" at <(.*)>: map has no entry for key "(.*)"$`)
+
+// ApplyTemplate apply the parameters on the template represented by the descriptor content
 func ApplyTemplate(u EkURL, descriptorContent []byte, parameters *TemplateContext) (out bytes.Buffer, err error) {
+	return applyTemplate(u.String(), descriptorContent, parameters)
+}
+
+// applyTemplate apply the parameters on the template represented by the content, the
+// name identifies the template into the errors
+func applyTemplate(name string, content []byte, parameters *TemplateContext) (out bytes.Buffer, err error) {
 
 	// Parse/execute it as a Go template
 	out = bytes.Buffer{}
-	tpl, err := template.New(u.String()).Funcs(template.FuncMap{"yaml": IndentYaml, "json": Json}).Parse(string(descriptorContent))
+	tpl := template.New(name).Funcs(templateFuncs(parameters))
+	if parameters.strict {
+		tpl = tpl.Option("missingkey=error")
+	}
+	tpl, err = tpl.Parse(string(content))
 	if err != nil {
 		return
 	}
@@ -22,3 +41,52 @@ func ApplyTemplate(u EkURL, descriptorContent []byte, parameters *TemplateContex
 
 	return
 }
+
+// templateError converts the error resulting of the strict templating of a
+// descriptor into a ValidationError naming the missing variable. The other
+// errors are returned unchanged.
+//
+// If exactLine is true the line of the error matches the descriptor content,
+// otherwise the line is the first one of the descriptor referencing the
+// missing variable.
+func templateError(location DescriptorLocation, content []byte, err error, exactLine bool) error {
+	if err == nil {
+		return nil
+	}
+	m := missingKeyError.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	// Keep the variable path up to the missing key
+	variable := m[3]
+	segments := strings.Split(variable, ".")
+	for i, s := range segments {
+		if s == m[4] {
+			variable = strings.Join(segments[:i+1], ".")
+			break
+		}
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	if !exactLine {
+		line = 0
+		for i, l := range strings.Split(string(content), "\n") {
+			if strings.Contains(l, variable) {
+				line = i + 1
+				break
+			}
+		}
+	}
+
+	l := DescriptorLocation{Descriptor: location.Descriptor, positions: location.positions}
+	if path, ok := location.positions.at(line); ok {
+		l = l.appendPath(path)
+	}
+	l.Line = line
+	if line > 0 && l.Column == 0 {
+		l.Column = 1
+	}
+	vErrs := ValidationErrors{}
+	vErrs.append(Error, "missing template variable: "+variable, l)
+	return vErrs
+}
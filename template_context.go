@@ -21,6 +21,13 @@ type (
 			EnvVars EnvVars
 		}
 		Runtime Parameters
+
+		// strict indicates if the templating must fail on missing variables
+		strict bool
+		// secrets holds the secret resolvers and the resolved secrets, shared with the clones
+		secrets *secrets
+		// keyRing holds the keys used to decrypt the encrypted values
+		keyRing KeyRing
 	}
 )
 
@@ -29,6 +36,7 @@ func CreateTemplateContext(params Parameters) *TemplateContext {
 	return &TemplateContext{
 		Vars:    params,
 		Runtime: make(map[string]interface{}),
+		secrets: newSecrets(),
 	}
 }
 
@@ -38,6 +46,9 @@ func CloneTemplateContext(other *TemplateContext, cr ComponentReferencer) (*Temp
 		Vars:    CloneParameters(other.Vars),
 		Runtime: CloneParameters(other.Runtime),
 		Model:   other.Model,
+		strict:  other.strict,
+		secrets: other.secretsHolder(),
+		keyRing: other.keyRing,
 	}
 	if o, ok := cr.(Describable); ok {
 		tplC.Component.Type = o.DescType()
@@ -59,3 +70,30 @@ func CloneTemplateContext(other *TemplateContext, cr ComponentReferencer) (*Temp
 func (cc *TemplateContext) mergeVars(others Parameters) {
 	cc.Vars = others.inherit(cc.Vars)
 }
+
+// SetStrict enables or disables the strict templating.
+//
+// In strict mode referencing a missing key of Vars, Runtime or
+// Component.Params fails the templating instead of rendering "<no value>".
+// Optional values can still be read using the "index" and "hasKey" functions.
+func (cc *TemplateContext) SetStrict(strict bool) {
+	cc.strict = strict
+}
+
+// IsStrict returns true if the strict templating is enabled
+func (cc *TemplateContext) IsStrict() bool {
+	return cc.strict
+}
+
+// SetKeyRing sets the keys used to decrypt the encrypted values of the
+// descriptors
+func (cc *TemplateContext) SetKeyRing(ring KeyRing) {
+	cc.keyRing = ring
+}
+
+// decrypt returns a copy of the value with all its encrypted scalars decrypted
+// using the key ring of the context, the decrypted values being kept, like
+// the resolved secrets, in order to be masked
+func (cc *TemplateContext) decrypt(path string, v interface{}) (interface{}, error) {
+	return cc.keyRing.decrypt(path, v, cc.secretsHolder().add)
+}
@@ -0,0 +1,427 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs returns the functions available into the descriptors templates
+// executed with the given context.
+//
+// The functions taking a value to process, like "default", expect it as last
+// argument in order to be usable into pipelines:
+//
+//	{{ .Vars.region | default "eu-west-1" }}
+func templateFuncs(ctx *TemplateContext) template.FuncMap {
+	return template.FuncMap{
+		"secret": func(ref string) (string, error) {
+			if ctx == nil {
+				return "", errors.New("secret: no template context")
+			}
+			return ctx.ResolveSecret(ref)
+		},
+		"yaml":          IndentYaml,
+		"json":          Json,
+		"default":       tplDefault,
+		"required":      tplRequired,
+		"coalesce":      tplCoalesce,
+		"ternary":       tplTernary,
+		"upper":         strings.ToUpper,
+		"lower":         strings.ToLower,
+		"trim":          strings.TrimSpace,
+		"split":         tplSplit,
+		"join":          tplJoin,
+		"b64enc":        tplB64enc,
+		"b64dec":        tplB64dec,
+		"sha256":        tplSha256,
+		"toJson":        tplToJson,
+		"fromJson":      tplFromJson,
+		"dict":          tplDict,
+		"list":          tplList,
+		"hasKey":        tplHasKey,
+		"keys":          tplKeys,
+		"semverCompare": tplSemverCompare,
+	}
+}
+
+// tplEmpty returns true if the value is nil or the zero value of its type, the
+// maps, slices and strings being empty when their length is 0
+func tplEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return reflect.DeepEqual(v, reflect.Zero(rv.Type()).Interface())
+}
+
+// tplDefault returns the value if not empty, the default one otherwise
+func tplDefault(def interface{}, v interface{}) interface{} {
+	if tplEmpty(v) {
+		return def
+	}
+	return v
+}
+
+// tplRequired returns the value or fails with the given message if it's empty
+func tplRequired(message string, v interface{}) (interface{}, error) {
+	if tplEmpty(v) {
+		return nil, errors.New(message)
+	}
+	return v, nil
+}
+
+// tplCoalesce returns the first non empty value
+func tplCoalesce(vs ...interface{}) interface{} {
+	for _, v := range vs {
+		if !tplEmpty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// tplTernary returns the first value if the condition is true, the second one otherwise
+func tplTernary(ifTrue interface{}, ifFalse interface{}, condition bool) interface{} {
+	if condition {
+		return ifTrue
+	}
+	return ifFalse
+}
+
+// tplSplit splits the string around each occurrence of the separator
+func tplSplit(sep string, s string) []string {
+	return strings.Split(s, sep)
+}
+
+// tplJoin joins the elements of the list using the separator
+func tplJoin(sep string, list interface{}) (string, error) {
+	if list == nil {
+		return "", nil
+	}
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: a list is expected, got %T", list)
+	}
+	elems := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		elems = append(elems, fmt.Sprintf("%v", rv.Index(i).Interface()))
+	}
+	return strings.Join(elems, sep), nil
+}
+
+// tplB64enc encodes the string in base64
+func tplB64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// tplB64dec decodes the base64 string
+func tplB64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// tplSha256 returns the hexadecimal sha256 sum of the string
+func tplSha256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// tplToJson serializes the value as JSON
+func tplToJson(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// tplFromJson deserializes the JSON string
+func tplFromJson(s string) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// tplDict builds a map from a list of key value pairs
+func tplDict(kvs ...interface{}) (map[string]interface{}, error) {
+	if len(kvs)%2 != 0 {
+		return nil, errors.New("dict: an even number of arguments is expected")
+	}
+	res := make(map[string]interface{}, len(kvs)/2)
+	for i := 0; i < len(kvs); i += 2 {
+		res[fmt.Sprintf("%v", kvs[i])] = kvs[i+1]
+	}
+	return res, nil
+}
+
+// tplList builds a list from the arguments
+func tplList(vs ...interface{}) []interface{} {
+	return vs
+}
+
+// tplHasKey returns true if the map contains the key
+func tplHasKey(m interface{}, key string) bool {
+	for _, k := range tplKeys(m) {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// tplKeys returns the keys of the map in alphabetical order
+func tplKeys(m interface{}) []string {
+	res := make([]string, 0)
+	if m == nil {
+		return res
+	}
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Map {
+		return res
+	}
+	for _, k := range rv.MapKeys() {
+		res = append(res, fmt.Sprintf("%v", k.Interface()))
+	}
+	sort.Strings(res)
+	return res
+}
+
+// tplSemverCompare returns true if the version matches the constraint.
+//
+// The constraint is a comma separated list of comparisons which must all
+// be satisfied, for example ">=1.2.0, <2".
+// The supported operators are "=", "!=", ">", ">=", "<" and "<=", "=" being
+// used when no operator is specified, as well as the ranges "~" and "^":
+//   - "~1.2.3" accepts the patches of the version: ">=1.2.3, <1.3.0"
+//   - "^1.2.3" accepts the minor versions: ">=1.2.3, <2.0.0", only the patches
+//     being accepted for a 0 major version
+func tplSemverCompare(constraint string, version string) (bool, error) {
+	v, err := parseSemver(version)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range strings.Split(constraint, ",") {
+		op, cs := splitSemverOperator(strings.TrimSpace(c))
+		if strings.IndexAny(cs, semverOperatorChars) == 0 {
+			return false, fmt.Errorf("semverCompare: unsupported operator in %q", strings.TrimSpace(c))
+		}
+		cv, err := parseSemver(cs)
+		if err != nil {
+			return false, err
+		}
+		cmp := v.compare(cv)
+		var ok bool
+		switch op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case "~":
+			ok = cmp >= 0 && v.compare(cv.tildeLimit()) < 0
+		case "^":
+			ok = cmp >= 0 && v.compare(cv.caretLimit()) < 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// semverOperators lists the operators supported into the semver constraints,
+// the longest ones first
+var semverOperators = []string{">=", "<=", "!=", ">", "<", "=", "~", "^"}
+
+// semverOperatorChars lists the characters used by the semver operators
+const semverOperatorChars = "<>=!~^"
+
+// splitSemverOperator splits a constraint into its operator, "=" if
+// none is specified, and its trimmed version
+func splitSemverOperator(c string) (string, string) {
+	for _, op := range semverOperators {
+		if strings.HasPrefix(c, op) {
+			return op, strings.TrimSpace(c[len(op):])
+		}
+	}
+	return "=", strings.TrimSpace(c)
+}
+
+// semver represents a semantic version
+type semver struct {
+	numbers [3]int
+	// parts is the number of version numbers specified
+	parts      int
+	prerelease []string
+}
+
+// parseSemver parses a version like "v1.2.3-rc.1+build", the missing minor
+// and patch numbers being considered as 0
+func parseSemver(s string) (semver, error) {
+	res := semver{}
+	v := strings.TrimPrefix(s, "v")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.Index(v, "-"); i >= 0 {
+		res.prerelease = strings.Split(v[i+1:], ".")
+		v = v[:i]
+		for _, id := range res.prerelease {
+			if !validPrerelease(id) {
+				return res, fmt.Errorf("invalid semantic version: %s", s)
+			}
+		}
+	}
+	parts := strings.Split(v, ".")
+	if v == "" || len(parts) > 3 {
+		return res, fmt.Errorf("invalid semantic version: %s", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return res, fmt.Errorf("invalid semantic version: %s", s)
+		}
+		res.numbers[i] = n
+	}
+	res.parts = len(parts)
+	return res, nil
+}
+
+// validPrerelease returns true if the prerelease identifier is made of
+// alphanumerics and hyphens, without leading zero if it's numeric
+func validPrerelease(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && c != '-' {
+			return false
+		}
+	}
+	_, numeric := prereleaseNumber(id)
+	return !numeric || id == "0" || id[0] != '0'
+}
+
+// prereleaseNumber returns the value of a numeric prerelease identifier
+func prereleaseNumber(id string) (int, bool) {
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(id)
+	return n, err == nil
+}
+
+// compare returns -1, 0 or 1 if the version is lower, equal or greater than
+// the other one, according to the precedence defined by the semver
+// specification.
+func (r semver) compare(o semver) int {
+	for i := range r.numbers {
+		if r.numbers[i] != o.numbers[i] {
+			if r.numbers[i] < o.numbers[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	// A version without prerelease has a higher precedence
+	switch {
+	case len(r.prerelease) == 0 && len(o.prerelease) == 0:
+		return 0
+	case len(r.prerelease) == 0:
+		return 1
+	case len(o.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(r.prerelease) && i < len(o.prerelease); i++ {
+		if c := comparePrerelease(r.prerelease[i], o.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	// A larger set of identifiers has a higher precedence
+	switch {
+	case len(r.prerelease) < len(o.prerelease):
+		return -1
+	case len(r.prerelease) > len(o.prerelease):
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease compares two prerelease identifiers, numerically if they
+// are both numeric, lexically in ASCII order if they are both alphanumeric,
+// the numeric ones having a lower precedence than the alphanumeric ones.
+func comparePrerelease(a, b string) int {
+	na, aNumeric := prereleaseNumber(a)
+	nb, bNumeric := prereleaseNumber(b)
+	switch {
+	case aNumeric && bNumeric:
+		if na == nb {
+			return 0
+		}
+		if na < nb {
+			return -1
+		}
+		return 1
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// tildeLimit returns the lowest version excluded by the "~" operator: the
+// next minor version, or the next major one if only the major is specified
+func (r semver) tildeLimit() semver {
+	if r.parts == 1 {
+		return semverLimit(r.numbers[0]+1, 0, 0)
+	}
+	return semverLimit(r.numbers[0], r.numbers[1]+1, 0)
+}
+
+// caretLimit returns the lowest version excluded by the "^" operator: the
+// next version changing the left-most non-zero number
+func (r semver) caretLimit() semver {
+	switch {
+	case r.numbers[0] > 0 || r.parts == 1:
+		return semverLimit(r.numbers[0]+1, 0, 0)
+	case r.numbers[1] > 0 || r.parts == 2:
+		return semverLimit(0, r.numbers[1]+1, 0)
+	}
+	return semverLimit(0, 0, r.numbers[2]+1)
+}
+
+// semverLimit returns the lowest version with the given numbers, including
+// the prereleases
+func semverLimit(major, minor, patch int) semver {
+	return semver{numbers: [3]int{major, minor, patch}, parts: 3, prerelease: []string{"0"}}
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{
+		"name":  "Ekara",
+		"empty": "",
+		"list":  []interface{}{"a", "b"},
+		"map":   map[interface{}]interface{}{"k2": "v2", "k1": "v1"},
+	}))
+
+	tests := map[string]string{
+		`{{ .Vars.empty | default "def" }}`:                       "def",
+		`{{ .Vars.name | default "def" }}`:                        "Ekara",
+		`{{ .Vars.missing | default "def" }}`:                     "def",
+		`{{ coalesce .Vars.empty .Vars.missing .Vars.name }}`:     "Ekara",
+		`{{ eq .Vars.name "Ekara" | ternary "yes" "no" }}`:        "yes",
+		`{{ .Vars.name | upper }}-{{ .Vars.name | lower }}`:       "EKARA-ekara",
+		`{{ trim "  x  " }}`:                                      "x",
+		`{{ split "," "a,b,c" | join "-" }}`:                      "a-b-c",
+		`{{ .Vars.list | join "," }}`:                             "a,b",
+		`{{ .Vars.name | b64enc }}`:                               "RWthcmE=",
+		`{{ "RWthcmE=" | b64dec }}`:                               "Ekara",
+		`{{ "abc" | sha256 }}`:                                    "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		`{{ dict "a" 1 "b" "x" | toJson }}`:                       `{"a":1,"b":"x"}`,
+		`{{ $m := fromJson "{\"a\":\"b\"}" }}{{ $m.a }}`:          "b",
+		`{{ list 1 "a" | toJson }}`:                               `[1,"a"]`,
+		`{{ hasKey .Vars.map "k1" }}-{{ hasKey .Vars.map "k3" }}`: "true-false",
+		`{{ keys .Vars.map | join "," }}`:                         "k1,k2",
+		`{{ semverCompare ">=1.2.0, <2" "1.10.1" }}`:              "true",
+		`{{ semverCompare ">=1.2.0, <2" "2.0.0" }}`:               "false",
+		`{{ semverCompare "<1.0.0" "v1.0.0-rc1" }}`:               "true",
+		`{{ semverCompare "1.2" "1.2.0" }}`:                       "true",
+	}
+	for tpl, expected := range tests {
+		out, err := applyTemplate("test", []byte(tpl), ctx)
+		if assert.Nil(t, err, tpl) {
+			assert.Equal(t, expected, out.String(), tpl)
+		}
+	}
+}
+
+func TestTemplateFuncsErrors(t *testing.T) {
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{}))
+	for _, tpl := range []string{
+		`{{ .Vars.missing | required "region is required" }}`,
+		`{{ "%%%" | b64dec }}`,
+		`{{ fromJson "{" }}`,
+		`{{ dict "a" }}`,
+		`{{ semverCompare ">=a.b" "1.0.0" }}`,
+		`{{ semverCompare "=>1.0.0" "1.0.0" }}`,
+		`{{ semverCompare ">>1.0.0" "1.0.0" }}`,
+		`{{ semverCompare "~>1.0.0" "1.0.0" }}`,
+		`{{ semverCompare "1.0.0" "1.0.0-rc.01" }}`,
+	} {
+		_, err := applyTemplate("test", []byte(tpl), ctx)
+		assert.NotNil(t, err, tpl)
+	}
+	_, err := applyTemplate("test", []byte(`{{ .Vars.missing | required "region is required" }}`), ctx)
+	assert.Contains(t, err.Error(), "region is required")
+}
+
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		// The alphanumeric identifiers are compared in ASCII order
+		{"<1.0.0-rc10", "1.0.0-rc2", false},
+		{">1.0.0-rc10", "1.0.0-rc2", true},
+		{"<1.0.0-rc.10", "1.0.0-rc.2", true},
+		{">1.0.0-alpha", "1.0.0-alpha.1", true},
+		{">1.0.0-alpha.1", "1.0.0-alpha.beta", true},
+		{">1.0.0-beta.2", "1.0.0-beta.11", true},
+		{">1.0.0-rc.1", "1.0.0", true},
+		{"=1.0.0", "1.0.0+build", true},
+		{">=v1.2", "1.2.0", true},
+		{">= v1.2, != 1.3.0", "1.3.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1.2.3", "1.3.0-rc.1", false},
+		{"~1", "1.9.0", true},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+	}
+	for _, test := range tests {
+		ok, err := tplSemverCompare(test.constraint, test.version)
+		if assert.Nil(t, err, test.constraint) {
+			assert.Equal(t, test.expected, ok, "%s %s", test.constraint, test.version)
+		}
+	}
+
+	_, err := tplSemverCompare("=>1.0.0", "1.0.0")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "semverCompare: unsupported operator in \"=>1.0.0\"", err.Error())
+	}
+	_, err = tplSemverCompare(">= >1.0.0", "1.0.0")
+	assert.NotNil(t, err)
+	_, err = tplSemverCompare(">=", "1.0.0")
+	assert.NotNil(t, err)
+}
+
+func TestSemverPrecedence(t *testing.T) {
+	// The example ordering of the semver specification
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0"}
+	for i := range ordered {
+		vi, err := parseSemver(ordered[i])
+		if !assert.Nil(t, err) {
+			continue
+		}
+		for j := range ordered {
+			vj, err := parseSemver(ordered[j])
+			if !assert.Nil(t, err) {
+				continue
+			}
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			assert.Equal(t, expected, vi.compare(vj), "%s %s", ordered[i], ordered[j])
+		}
+	}
+}
+
+func TestTemplateFuncsIntoVars(t *testing.T) {
+	content := `
+vars:
+  region: '{{ .Vars.region | default "eu-west-1" }}'
+  zone: '{{ .Vars.zone | default "eu-west-1" | upper }}'
+name: '{{ .Vars.zone | lower }}'
+`
+	yamlEnv, err := ParseYamlDescriptorBytes("test", []byte(content), CreateTemplateContext(CreateParameters(map[string]interface{}{})))
+	if assert.Nil(t, err) {
+		assert.Equal(t, "eu-west-1", yamlEnv.Vars["region"])
+		assert.Equal(t, "EU-WEST-1", yamlEnv.Vars["zone"])
+		assert.Equal(t, "eu-west-1", yamlEnv.Name)
+	}
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictTemplate(t *testing.T) {
+	content, err := ioutil.ReadFile("./testdata/yaml/template/strict.yaml")
+	assert.Nil(t, err)
+
+	// Without strict mode the missing variable is rendered as "<no value>"
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{}))
+	yamlEnv, err := ParseYamlDescriptorBytes("strict.yaml", content, ctx)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "<no value>", yamlEnv.Providers["aws"].Params["zone"])
+	}
+
+	ctx = CreateTemplateContext(CreateParameters(map[string]interface{}{}))
+	ctx.SetStrict(true)
+	assert.True(t, ctx.IsStrict())
+	_, err = ParseYamlDescriptorBytes("strict.yaml", content, ctx)
+	if assert.NotNil(t, err) {
+		vErrs, ok := err.(ValidationErrors)
+		if assert.True(t, ok) && assert.Equal(t, 1, len(vErrs.Errors)) {
+			e := vErrs.Errors[0]
+			assert.Equal(t, Error, e.ErrorType)
+			assert.Equal(t, "missing template variable: .Vars.zone", e.Message)
+			assert.Equal(t, "strict.yaml", e.Location.Descriptor)
+			assert.Equal(t, "providers.aws.params.zone", e.Location.Path)
+			assert.Equal(t, 10, e.Location.Line)
+		}
+	}
+
+	// The strict mode is kept by the clone
+	c, err := CloneTemplateContext(ctx, nil)
+	assert.Nil(t, err)
+	assert.True(t, c.IsStrict())
+}
+
+func TestStrictTemplateVars(t *testing.T) {
+	content := []byte(`name: strict
+vars:
+  region: "{{ .Vars.typo.region }}"
+`)
+	ctx := CreateTemplateContext(CreateParameters(map[string]interface{}{}))
+	ctx.SetStrict(true)
+	_, err := ParseYamlDescriptorBytes("vars.yaml", content, ctx)
+	if assert.NotNil(t, err) {
+		vErrs, ok := err.(ValidationErrors)
+		if assert.True(t, ok) && assert.Equal(t, 1, len(vErrs.Errors)) {
+			assert.Equal(t, "missing template variable: .Vars.typo", vErrs.Errors[0].Message)
+			assert.Equal(t, "vars.region", vErrs.Errors[0].Location.Path)
+			assert.Equal(t, 3, vErrs.Errors[0].Location.Line)
+		}
+	}
+}
+
+func TestDescriptorVariables(t *testing.T) {
+	content, err := ioutil.ReadFile("./testdata/yaml/template/variables.yaml")
+	assert.Nil(t, err)
+
+	vars, err := DescriptorVariables("variables.yaml", content)
+	assert.Nil(t, err)
+	paths := make([]string, 0)
+	for _, v := range vars {
+		paths = append(paths, v.Path)
+	}
+	assert.Equal(t, []string{".Component.Name", ".Runtime.region", ".Vars.aws", ".Vars.aws.region", ".Vars.name", ".Vars.zones"}, paths)
+
+	for _, v := range vars {
+		switch v.Path {
+		case ".Vars.name":
+			if assert.Equal(t, 2, len(v.Locations)) {
+				assert.Equal(t, "name", v.Locations[0].Path)
+				assert.Equal(t, 1, v.Locations[0].Line)
+				assert.Equal(t, "providers.aws.params.name", v.Locations[1].Path)
+				assert.Equal(t, 16, v.Locations[1].Line)
+			}
+		case ".Vars.aws.region":
+			if assert.Equal(t, 1, len(v.Locations)) {
+				assert.Equal(t, 10, v.Locations[0].Line)
+			}
+		}
+	}
+
+	_, err = DescriptorVariables("invalid.yaml", []byte("{{ .Vars.name "))
+	assert.NotNil(t, err)
+}
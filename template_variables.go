@@ -0,0 +1,152 @@
+package model
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+type (
+	// TemplateVariable represents a variable of the template context
+	// referenced by a descriptor
+	TemplateVariable struct {
+		// Path is the path of the variable into the template context, for
+		// example ".Vars.region"
+		Path string
+		// Locations are the places, into the descriptor, where the variable is referenced
+		Locations []DescriptorLocation
+	}
+)
+
+// DescriptorVariables returns, sorted by path, all the variables of the
+// template context referenced by the given descriptor content.
+//
+// The variables referenced relatively to the elements of a "range" action
+// cannot be resolved and are ignored.
+func DescriptorVariables(name string, content []byte) ([]TemplateVariable, error) {
+	tpl, err := template.New(name).Funcs(templateFuncs(nil)).Parse(string(content))
+	if err != nil {
+		return []TemplateVariable{}, err
+	}
+
+	positions := readYamlPositions(content)
+	found := make(map[string]*TemplateVariable)
+	for _, t := range tpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		collectVariables(t.Tree, t.Tree.Root, "", func(path string, n parse.Node) {
+			l := DescriptorLocation{Descriptor: name, positions: positions}
+			loc, _ := t.Tree.ErrorContext(n)
+			parts := strings.Split(loc, ":")
+			if len(parts) >= 3 {
+				l.Line, _ = strconv.Atoi(parts[len(parts)-2])
+				l.Column, _ = strconv.Atoi(parts[len(parts)-1])
+				if p, ok := positions.at(l.Line); ok {
+					l.Path = p
+				}
+			}
+			v, ok := found[path]
+			if !ok {
+				v = &TemplateVariable{Path: path}
+				found[path] = v
+			}
+			v.Locations = append(v.Locations, l)
+		})
+	}
+
+	res := make([]TemplateVariable, 0, len(found))
+	for _, v := range found {
+		res = append(res, *v)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+	return res, nil
+}
+
+// collectVariables walks the template nodes calling the visitor for each
+// referenced variable.
+//
+// The dot is the path of the current context, "" for the root of the template
+// context and "-" when the context cannot be resolved.
+func collectVariables(tree *parse.Tree, node parse.Node, dot string, visit func(path string, n parse.Node)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			collectVariables(tree, c, dot, visit)
+		}
+	case *parse.ActionNode:
+		collectVariables(tree, n.Pipe, dot, visit)
+	case *parse.IfNode:
+		collectVariables(tree, n.Pipe, dot, visit)
+		collectVariables(tree, n.List, dot, visit)
+		collectVariables(tree, n.ElseList, dot, visit)
+	case *parse.WithNode:
+		collectVariables(tree, n.Pipe, dot, visit)
+		collectVariables(tree, n.List, pipeVariable(n.Pipe, dot), visit)
+		collectVariables(tree, n.ElseList, dot, visit)
+	case *parse.RangeNode:
+		collectVariables(tree, n.Pipe, dot, visit)
+		collectVariables(tree, n.List, "-", visit)
+		collectVariables(tree, n.ElseList, dot, visit)
+	case *parse.TemplateNode:
+		collectVariables(tree, n.Pipe, dot, visit)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			collectVariables(tree, c, dot, visit)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			collectVariables(tree, a, dot, visit)
+		}
+	case *parse.FieldNode, *parse.VariableNode, *parse.ChainNode:
+		if path, ok := nodeVariable(n, dot); ok {
+			visit(path, n)
+		} else if c, ok := n.(*parse.ChainNode); ok {
+			collectVariables(tree, c.Node, dot, visit)
+		}
+	}
+}
+
+// pipeVariable returns the path of the variable returned by the pipe, or "-"
+// if the pipe doesn't simply return a variable
+func pipeVariable(pipe *parse.PipeNode, dot string) string {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return "-"
+	}
+	if path, ok := nodeVariable(pipe.Cmds[0].Args[0], dot); ok {
+		return path
+	}
+	return "-"
+}
+
+// nodeVariable returns the path of the variable referenced by the node
+func nodeVariable(node parse.Node, dot string) (string, bool) {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		if dot == "-" {
+			return "", false
+		}
+		return dot + "." + strings.Join(n.Ident, "."), true
+	case *parse.VariableNode:
+		// Only the fields of the root context "$" can be resolved
+		if len(n.Ident) < 2 || n.Ident[0] != "$" {
+			return "", false
+		}
+		return "." + strings.Join(n.Ident[1:], "."), true
+	case *parse.ChainNode:
+		if f, ok := n.Node.(*parse.FieldNode); ok && dot != "-" {
+			return dot + "." + strings.Join(append(append([]string{}, f.Ident...), n.Field...), "."), true
+		}
+	}
+	return "", false
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -27,3 +29,14 @@ func union(a, b []string) []string {
 	}
 	return res
 }
+
+// sortedKeys returns the keys of a map keyed by strings, in alphabetical order
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	res := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		res = append(res, k.String())
+	}
+	sort.Strings(res)
+	return res
+}
@@ -23,6 +23,12 @@ type (
 		ErrorType ErrorType
 		// Location represents the place, within the descriptor, where the error occurred
 		Location DescriptorLocation
+		// Component is the id of the component holding the descriptor which
+		// has introduced the invalid content, if known
+		Component string `json:",omitempty"`
+		// Origins are all the descriptors which have set the invalid content, the
+		// last one being the descriptor where the error occurred
+		Origins []Origin `json:",omitempty"`
 		// Message represents a human readable message telling what need to be
 		// fixed into the descriptor to get rid of this error
 		Message string
@@ -54,6 +60,9 @@ type (
 		//Repo contains the list of components where to look for the one matching
 		//the reference
 		Repo map[string]interface{}
+		//Removed indicates if the referenced component has been removed while
+		//customizing the environment
+		Removed bool
 	}
 	// validatableReferencer allows to get a validatable reference to a remote component
 	// into the environment descriptor.
@@ -141,7 +150,11 @@ var ErrorOnInvalid = valid(Error)
 func (ve ValidationErrors) Error() string {
 	s := "Validation errors or warnings have occurred:\n"
 	for _, err := range ve.Errors {
-		s = s + "\t" + err.ErrorType.String() + ": " + err.Message + " @" + err.Location.Path + "\n\tin: " + err.Location.Descriptor + "\n\t"
+		s = s + "\t" + err.ErrorType.String() + ": " + err.Message + " @" + err.Location.Path + "\n\tin: " + err.Location.position()
+		if err.Component != "" {
+			s = s + " (" + err.Component + ")"
+		}
+		s = s + "\n\t"
 	}
 	return s
 }
@@ -356,7 +369,11 @@ func checkValidReference(t ErrorType, c validatableReferencer) ValidationErrors
 		}
 	} else {
 		if _, ok := ref.Repo[ref.Id]; !ok {
-			vErrs.append(t, "reference to unknown "+ref.Type+": "+ref.Id, ref.Location)
+			if ref.Removed {
+				vErrs.append(t, "reference to removed "+ref.Type+": "+ref.Id, ref.Location)
+			} else {
+				vErrs.append(t, "reference to unknown "+ref.Type+": "+ref.Id, ref.Location)
+			}
 		}
 	}
 	return vErrs
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DescriptorVersion specifies the version of the descriptor grammar
+	// supported by this model.
+	//
+	// The descriptors without version are assumed to use this version. The
+	// grammar has not changed in an incompatible way yet, so there is no older
+	// version to migrate.
+	DescriptorVersion = 1
+)
+
+// checkDescriptorVersion returns an error if the templated descriptor content
+// declares a version of the grammar which is not supported
+func checkDescriptorVersion(content []byte) error {
+	tree := struct {
+		Version interface{}
+	}{}
+	if err := yaml.Unmarshal(content, &tree); err != nil {
+		return err
+	}
+	if tree.Version == nil {
+		return nil
+	}
+	v, ok := tree.Version.(int)
+	if !ok {
+		return fmt.Errorf("invalid descriptor version: %v", tree.Version)
+	}
+	if v != DescriptorVersion {
+		return fmt.Errorf("unsupported descriptor version %d, the supported version is %d", v, DescriptorVersion)
+	}
+	return nil
+}
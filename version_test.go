@@ -0,0 +1,34 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescriptorWithoutVersion(t *testing.T) {
+	yamlEnv, e := ParseYamlDescriptor(buildURL(t, "./testdata/yaml/version/unversioned.yaml"), &TemplateContext{})
+	assert.Nil(t, e)
+	assert.Equal(t, 0, yamlEnv.Version)
+	assert.Equal(t, "ekara-platform/distribution", yamlEnv.Ekara.Parent.Repository)
+	assert.Equal(t, 0, len(yamlEnv.vErrs.Errors))
+}
+
+func TestDescriptorCurrentVersion(t *testing.T) {
+	yamlEnv, e := ParseYamlDescriptor(buildURL(t, "./testdata/yaml/version/versioned.yaml"), &TemplateContext{})
+	assert.Nil(t, e)
+	assert.Equal(t, DescriptorVersion, yamlEnv.Version)
+	assert.Equal(t, "name_value", yamlEnv.Name)
+	assert.Equal(t, 0, len(yamlEnv.vErrs.Errors))
+}
+
+func TestDescriptorUnsupportedVersion(t *testing.T) {
+	_, e := ParseYamlDescriptor(buildURL(t, "./testdata/yaml/version/unsupported.yaml"), &TemplateContext{})
+	if assert.NotNil(t, e) {
+		assert.True(t, strings.Contains(e.Error(), "unsupported descriptor version 3"))
+	}
+
+	assert.NotNil(t, checkDescriptorVersion([]byte("version: v2\n")))
+	assert.Nil(t, checkDescriptorVersion([]byte("name: name_value\n")))
+}
@@ -22,7 +22,7 @@ func createGlobalVolumes(env *Environment, location DescriptorLocation, yamlEnv
 	res := make(map[string]*GlobalVolume)
 	for name, yamlVol := range yamlEnv.Volumes {
 		gv := GlobalVolume{}
-		gv.Content = make([]VolumeContent, len(yamlVol.Content))
+		gv.Content = make([]VolumeContent, 0, len(yamlVol.Content))
 		for _, v := range yamlVol.Content {
 			gv.Content = append(gv.Content, VolumeContent{
 				Component: createComponentRef(env, location.appendPath("component"), v.Component, false),
@@ -3,21 +3,21 @@ package model
 type (
 	// yaml tag for the proxy details
 	yamlProxy struct {
-		Http    string `yaml:"http_proxy"`
-		Https   string `yaml:"https_proxy"`
-		NoProxy string `yaml:"no_proxy"`
+		Http    string `yaml:"http_proxy,omitempty"`
+		Https   string `yaml:"https_proxy,omitempty"`
+		NoProxy string `yaml:"no_proxy,omitempty"`
 	}
 
 	// yaml tag for stuff to be copied on volumes
 	yamlCopy struct {
 		//Once indicates if the copy should be done only on one node matching the targeted labels
-		Once bool
+		Once bool `yaml:",omitempty"`
 		// The volume path where to copy the content
-		Path string
+		Path string `yaml:",omitempty"`
 		// Labels to restrict the copy to some node sets
 		yamlLabel `yaml:",inline"`
 		// The list of path patterns identifying content to be copied
-		Sources []string `yaml:"sources"`
+		Sources []string `yaml:"sources,omitempty"`
 	}
 
 	// yaml tag for parameters
@@ -53,9 +53,9 @@ type (
 	// yaml tag for component
 	yamlComponent struct {
 		// The source repository where the component lives
-		Repository string
+		Repository string `yaml:",omitempty"`
 		// The ref (branch or tag) of the component to use
-		Ref string
+		Ref string `yaml:",omitempty"`
 		// The authentication parameters
 		yamlAuth `yaml:",inline"`
 	}
@@ -78,13 +78,13 @@ type (
 
 	// yaml reference to provider
 	yamlProviderRef struct {
-		Name string
+		Name string `yaml:",omitempty"`
 		// The overriding provider parameters
 		yamlParams `yaml:",inline"`
 		// The overriding provider environment variables
 		yamlEnv `yaml:",inline"`
 		// The overriding provider proxy
-		Proxy yamlProxy
+		Proxy yamlProxy `yaml:",omitempty"`
 	}
 
 	// yaml reference to orchestrator
@@ -100,11 +100,13 @@ type (
 		// The referenced task
 		Task string
 		// Prefix, optional string used to prefix the stored hook results.*
-		Prefix string
+		Prefix string `yaml:",omitempty"`
 		// The overriding parameters
 		yamlParams `yaml:",inline"`
 		// The overriding environment variables
 		yamlEnv `yaml:",inline"`
+		// Indicates if the inherited references to the task must be removed from the hook
+		Remove bool `yaml:",omitempty"`
 	}
 
 	//yaml tag for hooks
@@ -116,37 +118,132 @@ type (
 	}
 
 	yamlEkara struct {
-		Base       string `yaml:",omitempty"`
-		Parent     yamlComponent
-		Components map[string]yamlComponent
+		Base       string                   `yaml:",omitempty"`
+		Parent     yamlComponent            `yaml:",omitempty"`
+		Components map[string]yamlComponent `yaml:",omitempty"`
 		// The list of path patterns where to apply the template mechanism
-		Templates []string `yaml:"templates"`
+		Templates []string `yaml:"templates,omitempty"`
 		// The list of custom playbooks
 		yamlPlaybooks `yaml:",inline"`
 	}
 
 	yamlNode struct {
 		// The number of instances to create within the node set
-		Instances int
+		Instances int `yaml:",omitempty"`
 		// The provider used to create the node set and its settings
-		Provider yamlProviderRef
+		Provider yamlProviderRef `yaml:",omitempty"`
 		// The orchestrator settings for this node set
-		Orchestrator yamlOrchestratorRef
+		Orchestrator yamlOrchestratorRef `yaml:",omitempty"`
 		// The orchestrator settings for this node set
-		Volumes []yamlVolume
+		Volumes []yamlVolume `yaml:",omitempty"`
 		// The Hooks to be executed while creating the node set
-		Hooks struct {
-			Create yamlHook `yaml:",omitempty"`
-		} `yaml:",omitempty"`
+		Hooks yamlNodeHooks `yaml:",omitempty"`
 
 		// The labels associated with the nodeset
 		yamlLabel `yaml:",inline"`
+
+		// Indicates if the inherited node set must be removed
+		Remove bool `yaml:",omitempty"`
+	}
+
+	// yaml tag for the hooks of a node set
+	yamlNodeHooks struct {
+		Create yamlHook `yaml:",omitempty"`
+	}
+
+	// yaml tag for a task
+	yamlTask struct {
+		// Name of the task component
+		Component string `yaml:",omitempty"`
+		// The task parameters
+		yamlParams `yaml:",inline"`
+		// The task environment variables
+		yamlEnv `yaml:",inline"`
+		// The name of the playbook to launch the task
+		Playbook string `yaml:",omitempty"`
+		// The Hooks to be executed in addition the the main task playbook
+		Hooks yamlTaskHooks `yaml:",omitempty"`
+		// Indicates if the inherited task must be removed
+		Remove bool `yaml:",omitempty"`
+	}
+
+	// yaml tag for the hooks of a task
+	yamlTaskHooks struct {
+		Execute yamlHook `yaml:",omitempty"`
+	}
+
+	// yaml tag for the orchestrator
+	yamlOrchestrator struct {
+		// Name of the orchestrator component
+		Component string `yaml:",omitempty"`
+		// The orchestrator parameters
+		yamlParams `yaml:",inline"`
+		// The orchestrator environment variables
+		yamlEnv `yaml:",inline"`
+	}
+
+	// yaml tag for a provider
+	yamlProvider struct {
+		// Name of the provider component
+		Component string `yaml:",omitempty"`
+		// The provider parameters
+		yamlParams `yaml:",inline"`
+		// The provider environment variables
+		yamlEnv `yaml:",inline"`
+		// The provider proxy
+		Proxy yamlProxy `yaml:",omitempty"`
+	}
+
+	// yaml tag for a stack
+	yamlStack struct {
+		// Name of the stack component
+		Component string `yaml:",omitempty"`
+		// The name of the stack on which this one depends
+		DependsOn []string `yaml:"depends_on,omitempty"`
+		// The Hooks to be executed while deploying the stack
+		Hooks yamlStackHooks `yaml:",omitempty"`
+
+		// The parameters
+		yamlParams `yaml:",inline"`
+		// The environment variables
+		yamlEnv `yaml:",inline"`
+
+		// The stack content to be copied on volumes
+		Copies map[string]yamlCopy `yaml:",omitempty"`
+
+		// Custom playbook
+		Playbook string `yaml:",omitempty"`
+
+		// Indicates if the inherited stack must be removed
+		Remove bool `yaml:",omitempty"`
+	}
+
+	// yaml tag for the hooks of a stack
+	yamlStackHooks struct {
+		Deploy yamlHook `yaml:",omitempty"`
+	}
+
+	// yaml tag for the global hooks
+	yamlEnvironmentHooks struct {
+		Init    yamlHook `yaml:",omitempty"`
+		Create  yamlHook `yaml:",omitempty"`
+		Install yamlHook `yaml:",omitempty"`
+		Deploy  yamlHook `yaml:",omitempty"`
+		Delete  yamlHook `yaml:",omitempty"`
+	}
+
+	// yaml tag for a global volume
+	yamlGlobalVolume struct {
+		Content []yamlVolumeContent `yaml:",omitempty"`
 	}
 
 	// Definition of the Ekara environment
 	yamlEnvironment struct {
+		// The version of the descriptor grammar
+		Version int `yaml:",omitempty"`
+
 		// The name of the environment
-		Name string
+		Name string `yaml:",omitempty"`
 		// The qualifier of the environment
 		Qualifier string `yaml:",omitempty"`
 
@@ -154,87 +251,48 @@ type (
 		Description string `yaml:",omitempty"`
 
 		// The Ekara platform used to interact with the environment
-		Ekara yamlEkara
+		Ekara yamlEkara `yaml:",omitempty"`
+
+		// The inputs of the descriptor
+		yamlInputs `yaml:",inline"`
 
 		// The descriptor variables
 		yamlVars `yaml:",inline"`
 
 		// Tasks which can be run on the created environment
-		Tasks map[string]struct {
-			// Name of the task component
-			Component string
-			// The task parameters
-			yamlParams `yaml:",inline"`
-			// The task environment variables
-			yamlEnv `yaml:",inline"`
-			// The name of the playbook to launch the task
-			Playbook string `yaml:",omitempty"`
-			// The Hooks to be executed in addition the the main task playbook
-			Hooks struct {
-				Execute yamlHook `yaml:",omitempty"`
-			} `yaml:",omitempty"`
-		}
+		Tasks map[string]yamlTask `yaml:",omitempty"`
 
 		// Global definition of the orchestrator to install on the environment
-		Orchestrator struct {
-			// Name of the orchestrator component
-			Component string
-			// The orchestrator parameters
-			yamlParams `yaml:",inline"`
-			// The orchestrator environment variables
-			yamlEnv `yaml:",inline"`
-		}
+		Orchestrator yamlOrchestrator `yaml:",omitempty"`
 
 		// The list of all cloud providers required to create the environment
-		Providers map[string]struct {
-			// Name of the provider component
-			Component string
-			// The provider parameters
-			yamlParams `yaml:",inline"`
-			// The provider environment variables
-			yamlEnv `yaml:",inline"`
-			// The provider proxy
-			Proxy yamlProxy
-		}
+		Providers map[string]yamlProvider `yaml:",omitempty"`
 
 		// The list of node sets to create
-		Nodes map[string]yamlNode
+		Nodes map[string]yamlNode `yaml:",omitempty"`
 
 		// Software stacks to be installed on the environment
-		Stacks map[string]struct {
-			// Name of the stack component
-			Component string
-			// The name of the stack on which this one depends
-			DependsOn []string `yaml:"depends_on"`
-			// The Hooks to be executed while deploying the stack
-			Hooks struct {
-				Deploy yamlHook `yaml:",omitempty"`
-			} `yaml:",omitempty"`
-
-			// The parameters
-			yamlParams `yaml:",inline"`
-			// The environment variables
-			yamlEnv `yaml:",inline"`
-
-			// The stack content to be copied on volumes
-			Copies map[string]yamlCopy
-
-			// Custom playbook
-			Playbook string
-		}
+		Stacks map[string]yamlStack `yaml:",omitempty"`
 
 		// Global hooks
-		Hooks struct {
-			Init    yamlHook `yaml:",omitempty"`
-			Create  yamlHook `yaml:",omitempty"`
-			Install yamlHook `yaml:",omitempty"`
-			Deploy  yamlHook `yaml:",omitempty"`
-			Delete  yamlHook `yaml:",omitempty"`
-		} `yaml:",omitempty"`
+		Hooks yamlEnvironmentHooks `yaml:",omitempty"`
 
 		// Global volumes
-		Volumes map[string]struct {
-			Content []yamlVolumeContent `yaml:",omitempty"`
-		} `yaml:",omitempty"`
+		Volumes map[string]yamlGlobalVolume `yaml:",omitempty"`
+
+		// The paths of the sensitive values, like "providers.aws.params.secret_key",
+		// which must be masked when the environment is printed or exported
+		Sensitive []string `yaml:",omitempty"`
+
+		// The paths, like "orchestrator.component" or "providers.*.proxy", which
+		// cannot be overridden by the descriptors customizing this one
+		Sealed []string `yaml:",omitempty"`
+
+		// The positions of the elements into the original descriptor
+		positions yamlPositions
+		// The validation errors detected while parsing the descriptor
+		vErrs ValidationErrors
+		// The secrets resolved and the values decrypted while parsing the descriptor
+		sensitiveValues []string
 	}
 )
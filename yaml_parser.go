@@ -2,45 +2,126 @@ package model
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+
 	"gopkg.in/yaml.v2"
 )
 
 // ParseYamlDescriptor returns an environment based on parsing of the
 // descriptor located at the provided URL.
 func ParseYamlDescriptor(u EkURL, context *TemplateContext) (env yamlEnvironment, err error) {
+	env, _, err = parseYamlDescriptorURL(u, context, false, Warning)
+	return
+}
+
+// ParseYamlDescriptorStrict returns an environment based on parsing of the
+// descriptor located at the provided URL, checking that all the keys of the
+// descriptor belong to its grammar.
+//
+// Each unknown key is reported, with its location, as a ValidationError of the
+// given type. The unknown keys are also kept into the returned environment in
+// order to be reported again by the validation of the environment created
+// from it.
+func ParseYamlDescriptorStrict(u EkURL, context *TemplateContext, t ErrorType) (env yamlEnvironment, vErrs ValidationErrors, err error) {
+	return parseYamlDescriptorURL(u, context, true, t)
+}
+
+// ParseYamlDescriptorBytes returns an environment based on parsing of the
+// given descriptor content.
+//
+// The name is used to identify the descriptor into the templating errors and
+// into the locations of the environment content.
+func ParseYamlDescriptorBytes(name string, content []byte, context *TemplateContext) (env yamlEnvironment, err error) {
+	env, _, err = parseYamlDescriptor(name, content, context, false, Warning)
+	return
+}
+
+// ParseYamlDescriptorReader returns an environment based on parsing of the
+// descriptor content read from the given reader.
+//
+// The name is used to identify the descriptor into the templating errors and
+// into the locations of the environment content.
+func ParseYamlDescriptorReader(name string, r io.Reader, context *TemplateContext) (env yamlEnvironment, err error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+	return ParseYamlDescriptorBytes(name, content, context)
+}
+
+// ParseYamlDescriptorBytesStrict returns an environment based on parsing of
+// the given descriptor content, checking that all the keys of the descriptor
+// belong to its grammar.
+//
+// See ParseYamlDescriptorStrict for the reporting of the unknown keys.
+func ParseYamlDescriptorBytesStrict(name string, content []byte, context *TemplateContext, t ErrorType) (env yamlEnvironment, vErrs ValidationErrors, err error) {
+	return parseYamlDescriptor(name, content, context, true, t)
+}
 
+// ParseYamlDescriptorReaderStrict returns an environment based on parsing of
+// the descriptor content read from the given reader, checking that all the
+// keys of the descriptor belong to its grammar.
+//
+// See ParseYamlDescriptorStrict for the reporting of the unknown keys.
+func ParseYamlDescriptorReaderStrict(name string, r io.Reader, context *TemplateContext, t ErrorType) (env yamlEnvironment, vErrs ValidationErrors, err error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+	return ParseYamlDescriptorBytesStrict(name, content, context, t)
+}
+
+func parseYamlDescriptorURL(u EkURL, context *TemplateContext, strict bool, t ErrorType) (env yamlEnvironment, vErrs ValidationErrors, err error) {
 	// Read descriptor content
 	content, err := u.ReadUrl()
 	if err != nil {
 		return
 	}
+	return parseYamlDescriptor(u.String(), content, context, strict, t)
+}
 
-	//Parse just the "vars:" section of the descriptor
-	tempsVars, err := readEnvironmentVars(content)
+func parseYamlDescriptor(name string, content []byte, context *TemplateContext, strict bool, t ErrorType) (env yamlEnvironment, vErrs ValidationErrors, err error) {
+	// Keep the positions of the elements of the original descriptor
+	positions := readYamlPositions(content)
+	location := DescriptorLocation{Descriptor: name, positions: positions}
+
+	templated, err := templateDescriptor(location, content, context)
 	if err != nil {
-		err = fmt.Errorf(" yaml error in %s : %s", u.String(), err.Error())
 		return
 	}
 
-	//Fill the TemplateContext with the vars content of the descriptor
-	err = tempsVars.fillContext(u, context)
+	// Decrypt the encrypted values and resolve the secrets referenced by the parameters
+	templated, err = decryptDescriptorValues(templated, context)
 	if err != nil {
+		err = fmt.Errorf(" yaml error in %s : %s", name, err.Error())
 		return
 	}
-
-	// Template the content of the environment descriptor with the freshly
-	// parsed vars mixed with the params coming from the launch context.
-	out, err := ApplyTemplate(u, content, context)
+	templated, err = resolveDescriptorSecrets(templated, context)
 	if err != nil {
+		err = fmt.Errorf(" yaml error in %s : %s", name, err.Error())
 		return
 	}
 
 	// Unmarshal the resulting YAML to get an environment
-	err = yaml.Unmarshal(out.Bytes(), &env)
+	err = yaml.Unmarshal(templated, &env)
 	if err != nil {
-		err = fmt.Errorf(" yaml error in %s : %s", u.String(), err.Error())
+		err = fmt.Errorf(" yaml error in %s : %s", name, err.Error())
 		return
 	}
+	env.positions = positions
+	env.sensitiveValues = context.SecretValues()
+
+	if strict {
+		// Look for the keys unknown by the descriptor grammar
+		vErrs, err = unknownYamlKeys(templated, reflect.TypeOf(env), location, t)
+		if err != nil {
+			err = fmt.Errorf(" yaml error in %s : %s", name, err.Error())
+			return
+		}
+		env.vErrs.merge(vErrs)
+	}
 	return
 }
 
@@ -55,32 +136,84 @@ func ParseYamlDescriptorReferences(url EkURL, context *TemplateContext) (env Env
 	if err != nil {
 		return
 	}
+	return ParseYamlDescriptorReferencesBytes(url.String(), content, context)
+}
+
+// ParseYamlDescriptorReferencesBytes returns the component references,
+// declared and used, found into the given descriptor content.
+//
+// The name is used to identify the descriptor into the templating errors.
+func ParseYamlDescriptorReferencesBytes(name string, content []byte, context *TemplateContext) (env EnvironmentReferences, err error) {
+	templated, err := templateDescriptor(DescriptorLocation{Descriptor: name}, content, context)
+	if err != nil {
+		return
+	}
+
+	// Unmarshal the resulting YAML to get only references
+	err = yaml.Unmarshal(templated, &env)
+	if err != nil {
+		err = fmt.Errorf(" yaml error in %s : %s", name, err.Error())
+		return
+	}
+	return
+}
+
+// ParseYamlDescriptorReferencesReader returns the component references,
+// declared and used, found into the descriptor content read from the given
+// reader.
+//
+// The name is used to identify the descriptor into the templating errors.
+func ParseYamlDescriptorReferencesReader(name string, r io.Reader, context *TemplateContext) (env EnvironmentReferences, err error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+	return ParseYamlDescriptorReferencesBytes(name, content, context)
+}
+
+// templateDescriptor fills the context with the defaults of the descriptor
+// inputs and with the descriptor vars, checks the vars supplied for the
+// inputs, applies the template on the descriptor content and then checks the
+// version of the grammar used by the result.
+//
+// The errors detected on the inputs are returned as ValidationErrors.
+func templateDescriptor(location DescriptorLocation, content []byte, context *TemplateContext) ([]byte, error) {
+	name := location.Descriptor
 
 	//Parse just the "vars:" section of the descriptor
 	tempsVars, err := readEnvironmentVars(content)
 	if err != nil {
-		err = fmt.Errorf(" yaml error in %s : %s", url.String(), err.Error())
-		return
+		return nil, fmt.Errorf(" yaml error in %s : %s", name, err.Error())
+	}
+
+	//Parse just the "inputs:" section of the descriptor
+	inputs, err := readEnvironmentInputs(content)
+	if err != nil {
+		return nil, fmt.Errorf(" yaml error in %s : %s", name, err.Error())
 	}
+	inputs.applyDefaults(context)
 
 	//Fill the TemplateContext with the vars content of the descriptor
-	err = tempsVars.fillContext(url, context)
+	err = tempsVars.fillContext(location, context)
 	if err != nil {
-		return
+		return nil, templateError(location, content, err, false)
+	}
+
+	// Check the vars supplied for the declared inputs
+	if vErrs := inputs.validate(location, context.Vars); vErrs.HasErrors() {
+		return nil, vErrs
 	}
 
 	// Template the content of the environment descriptor with the freshly
 	// parsed vars mixed with the params coming from the launch context.
-	out, err := ApplyTemplate(url, content, context)
+	out, err := applyTemplate(name, content, context)
 	if err != nil {
-		return
+		return nil, templateError(location, content, err, true)
 	}
 
-	// Unmarshal the resulting YAML to get only references
-	err = yaml.Unmarshal(out.Bytes(), &env)
-	if err != nil {
-		err = fmt.Errorf(" yaml error in %s : %s", url.String(), err.Error())
-		return
+	// Check the version of the grammar used by the descriptor
+	if err := checkDescriptorVersion(out.Bytes()); err != nil {
+		return nil, fmt.Errorf(" yaml error in %s : %s", name, err.Error())
 	}
-	return
+	return out.Bytes(), nil
 }
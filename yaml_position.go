@@ -0,0 +1,341 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// yamlPosition represents the position of an element within the original
+	// content of a descriptor
+	yamlPosition struct {
+		// line is the line number of the element, starting at 1
+		line int
+		// column is the column number of the element, starting at 1
+		column int
+	}
+
+	// yamlPositions maps the path of the elements of a descriptor, as used
+	// into a DescriptorLocation, to their position into the descriptor
+	yamlPositions map[string]yamlPosition
+
+	// yamlPositionFrame represents a level of nesting while reading the
+	// positions of a descriptor
+	yamlPositionFrame struct {
+		indent int
+		path   string
+		item   bool
+		items  int
+	}
+)
+
+// readYamlPositions reads the positions of all the keys and sequence items
+// of the given descriptor content.
+//
+// The content is scanned line by line, before any templating, in order to
+// get positions matching the original file; it cannot be parsed as YAML at
+// this stage because the template actions may make it invalid. Comments and
+// block scalars are ignored, the flow mappings and sequences, even spread
+// over several lines, are located down to their nested elements.
+//
+// The lines starting with a template action are ignored, as well as the keys
+// built by templating. The elements they produce are not located, callers
+// get the position of their closest parent through locate. The indexes of
+// the sequence items are the ones of the original content, the items
+// produced by a template loop are not counted.
+func readYamlPositions(content []byte) yamlPositions {
+	res := yamlPositions{}
+	stack := make([]yamlPositionFrame, 0)
+	blockIndent := -1
+
+	lines := strings.Split(string(content), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(strings.Replace(lines[i], "\t", " ", -1), "\r ")
+	}
+	for i := 0; i < len(lines); i++ {
+		raw := lines[i]
+		trimmed := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(trimmed)
+
+		if blockIndent >= 0 {
+			if trimmed == "" || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "{{") ||
+			strings.HasPrefix(trimmed, "---") || strings.HasPrefix(trimmed, "...") {
+			continue
+		}
+
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			// A sequence item belongs to the nearest key less indented or, for
+			// sequences not indented under their key, to the key at the same level
+			for len(stack) > 0 && (stack[len(stack)-1].indent > indent || (stack[len(stack)-1].indent == indent && stack[len(stack)-1].item)) {
+				stack = stack[:len(stack)-1]
+			}
+			parent := ""
+			index := 0
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1].path
+				index = stack[len(stack)-1].items
+				stack[len(stack)-1].items++
+			}
+			path := parent + fmt.Sprintf("[%d]", index)
+			res[path] = yamlPosition{line: i + 1, column: indent + 1}
+			stack = append(stack, yamlPositionFrame{indent: indent, path: path, item: true})
+
+			rest := strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " ")
+			indent = len(raw) - len(rest)
+			trimmed = rest
+			if trimmed == "" {
+				continue
+			}
+			if isFlowStart(trimmed) {
+				i = readFlowPositions(lines, i, indent, path, res)
+				continue
+			}
+		} else {
+			for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+		}
+
+		key, value, ok := splitYamlKey(trimmed)
+		if !ok {
+			continue
+		}
+		path := key
+		if len(stack) > 0 {
+			path = stack[len(stack)-1].path + "." + key
+		}
+		res[path] = yamlPosition{line: i + 1, column: indent + 1}
+		stack = append(stack, yamlPositionFrame{indent: indent, path: path})
+
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+		}
+		if isFlowStart(value) {
+			i = readFlowPositions(lines, i, len(raw)-len(value), path, res)
+		}
+	}
+	return res
+}
+
+// isFlowStart returns true if the value starts a flow mapping or sequence,
+// and not a template action
+func isFlowStart(value string) bool {
+	return strings.HasPrefix(value, "[") || (strings.HasPrefix(value, "{") && !strings.HasPrefix(value, "{{"))
+}
+
+// yamlFlowFrame represents a flow mapping or sequence being read
+type yamlFlowFrame struct {
+	// path is the path of the mapping or sequence
+	path string
+	// seq indicates if the frame is a sequence
+	seq bool
+	// items is the number of items already read from the sequence
+	items int
+	// value is the path of the current entry
+	value string
+	// entry indicates if the next token starts a new entry
+	entry bool
+}
+
+// readFlowPositions reads the positions of the elements of the flow
+// collection starting at the given line and column, and identified by the
+// given path. It returns the line where the collection ends.
+func readFlowPositions(lines []string, line int, column int, path string, res yamlPositions) int {
+	frames := make([]*yamlFlowFrame, 0)
+	for l := line; l < len(lines); l++ {
+		s := lines[l]
+		c := 0
+		if l == line {
+			c = column
+		}
+		for c < len(s) {
+			ch := s[c]
+			if ch == ' ' {
+				c++
+				continue
+			}
+			if ch == '#' && (c == 0 || s[c-1] == ' ') {
+				break
+			}
+			var top *yamlFlowFrame
+			if len(frames) > 0 {
+				top = frames[len(frames)-1]
+			}
+			switch {
+			case ch == ',':
+				if top != nil {
+					top.entry = true
+				}
+				c++
+				continue
+			case ch == '}' || ch == ']':
+				frames = frames[:len(frames)-1]
+				if len(frames) == 0 {
+					return l
+				}
+				c++
+				continue
+			}
+
+			valuePath := path
+			if top != nil {
+				if top.entry {
+					top.entry = false
+					if top.seq {
+						top.value = fmt.Sprintf("%s[%d]", top.path, top.items)
+						top.items++
+						res[top.value] = yamlPosition{line: l + 1, column: c + 1}
+					} else {
+						key, end := readFlowKey(s, c)
+						top.value = top.path + "." + key
+						res[top.value] = yamlPosition{line: l + 1, column: c + 1}
+						c = end
+						continue
+					}
+				}
+				valuePath = top.value
+			}
+
+			switch {
+			case strings.HasPrefix(s[c:], "{{"):
+				c = skipFlowTemplate(s, c)
+			case ch == '{' || ch == '[':
+				frames = append(frames, &yamlFlowFrame{path: valuePath, seq: ch == '[', entry: true})
+				c++
+			case ch == '"' || ch == '\'':
+				c = skipFlowQuoted(s, c)
+			default:
+				c = skipFlowScalar(s, c, top != nil && !top.seq)
+			}
+		}
+	}
+	return len(lines) - 1
+}
+
+// readFlowKey reads the key of a flow mapping entry starting at the given
+// column, it returns the unquoted key and the column following its colon
+func readFlowKey(s string, c int) (string, int) {
+	key := ""
+	end := c
+	if s[c] == '"' || s[c] == '\'' {
+		end = skipFlowQuoted(s, c)
+		key = strings.Trim(s[c:end], s[c:c+1])
+	} else {
+		end = skipFlowScalar(s, c, true)
+		key = strings.TrimRight(s[c:end], " ")
+	}
+	rest := strings.TrimLeft(s[end:], " ")
+	if strings.HasPrefix(rest, ":") {
+		end = len(s) - len(rest) + 1
+	}
+	return key, end
+}
+
+// skipFlowQuoted returns the column following the quoted scalar starting at the given column
+func skipFlowQuoted(s string, c int) int {
+	q := s[c]
+	for i := c + 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// skipFlowTemplate returns the column following the template action starting at the given column
+func skipFlowTemplate(s string, c int) int {
+	i := strings.Index(s[c:], "}}")
+	if i < 0 {
+		return len(s)
+	}
+	return c + i + 2
+}
+
+// skipFlowScalar returns the column following the plain scalar starting at
+// the given column, a key ending at its colon
+func skipFlowScalar(s string, c int, key bool) int {
+	for i := c; i < len(s); i++ {
+		switch {
+		case s[i] == ',' || s[i] == '}' || s[i] == ']':
+			return i
+		case s[i] == '#' && i > c && s[i-1] == ' ':
+			return i
+		case key && s[i] == ':' && (i+1 == len(s) || strings.ContainsAny(s[i+1:i+2], " ,}]")):
+			return i
+		case strings.HasPrefix(s[i:], "{{"):
+			i = skipFlowTemplate(s, i) - 1
+		}
+	}
+	return len(s)
+}
+
+// splitYamlKey splits a line content into its key and its value, the key
+// will be unquoted if required.
+func splitYamlKey(s string) (key string, value string, ok bool) {
+	if strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'") {
+		end := strings.Index(s[1:], s[0:1])
+		if end < 0 {
+			return "", "", false
+		}
+		key = s[1 : end+1]
+		rest := strings.TrimLeft(s[end+2:], " ")
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest[1:]), true
+	}
+	if strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[") {
+		return "", "", false
+	}
+	i := strings.Index(s, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(s, ":") {
+			return "", "", false
+		}
+		i = len(s) - 1
+	}
+	key = strings.TrimRight(s[:i], " ")
+	if key == "" || strings.Contains(key, "{{") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(s[i+1:]), true
+}
+
+// locate returns the position of the element matching the given path or, if
+// the element itself cannot be located, the position of its closest parent.
+func (r yamlPositions) locate(path string) (yamlPosition, bool) {
+	for path != "" {
+		if p, ok := r[path]; ok {
+			return p, true
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return yamlPosition{}, false
+}
+
+// at returns the path of the deepest element located on the given line
+func (r yamlPositions) at(line int) (string, bool) {
+	res := ""
+	column := 0
+	for path, p := range r {
+		if p.line == line && (p.column > column || (p.column == column && len(path) > len(res))) {
+			res = path
+			column = p.column
+		}
+	}
+	return res, res != ""
+}
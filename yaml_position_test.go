@@ -0,0 +1,153 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYamlPositions(t *testing.T) {
+	content := `# comment
+name: my_name
+"quoted": value
+nodes:
+  "*":
+    instances: 1
+  node1:
+    instances: {{ .Vars.instances }}
+    labels:
+      l1: v1
+hooks:
+  init:
+    before:
+      - task: task1
+        prefix: p1
+      - task: task2
+    after:
+    - task: task3
+description: |
+  name: not a key
+  other: not a key
+qualifier: q
+`
+	pos := readYamlPositions([]byte(content))
+	check := func(path string, line, column int) {
+		p, ok := pos[path]
+		if assert.True(t, ok, path) {
+			assert.Equal(t, line, p.line, path)
+			assert.Equal(t, column, p.column, path)
+		}
+	}
+	check("name", 2, 1)
+	check("quoted", 3, 1)
+	check("nodes", 4, 1)
+	check("nodes.*", 5, 3)
+	check("nodes.*.instances", 6, 5)
+	check("nodes.node1.instances", 8, 5)
+	check("nodes.node1.labels.l1", 10, 7)
+	check("hooks.init.before", 13, 5)
+	check("hooks.init.before[0]", 14, 7)
+	check("hooks.init.before[0].task", 14, 9)
+	check("hooks.init.before[0].prefix", 15, 9)
+	check("hooks.init.before[1].task", 16, 9)
+	check("hooks.init.after[0].task", 18, 7)
+	check("description", 19, 1)
+	check("qualifier", 22, 1)
+	assert.NotContains(t, pos, "description.name")
+	assert.NotContains(t, pos, "name.other")
+}
+
+func TestYamlPositionsLocateParent(t *testing.T) {
+	pos := readYamlPositions([]byte("nodes:\n  node1:\n    instances: 1\n"))
+	p, ok := pos.locate("nodes.node1.provider.name")
+	assert.True(t, ok)
+	assert.Equal(t, 2, p.line)
+	assert.Equal(t, 3, p.column)
+
+	_, ok = pos.locate("stacks.stack1")
+	assert.False(t, ok)
+}
+
+func TestYamlPositionsFlowCollections(t *testing.T) {
+	content := `nodes:
+  node1:
+    labels: {l1: v1, "l2": 'v, 2', l3: {{ .Vars.l3 }}}
+    zones: [a, "b", {name: c}]
+stacks:
+  stack1:
+    depends_on: [
+      stack2, # a comment
+      stack3
+    ]
+    params: {
+      db: {port: 5432},
+      users: [admin]}
+hooks:
+  init:
+    before:
+      - {task: task1, prefix: p1}
+      - task: task2
+qualifier: q
+`
+	pos := readYamlPositions([]byte(content))
+	check := func(path string, line, column int) {
+		p, ok := pos[path]
+		if assert.True(t, ok, path) {
+			assert.Equal(t, line, p.line, path)
+			assert.Equal(t, column, p.column, path)
+		}
+	}
+	check("nodes.node1.labels", 3, 5)
+	check("nodes.node1.labels.l1", 3, 14)
+	check("nodes.node1.labels.l2", 3, 22)
+	check("nodes.node1.labels.l3", 3, 36)
+	check("nodes.node1.zones[0]", 4, 13)
+	check("nodes.node1.zones[1]", 4, 16)
+	check("nodes.node1.zones[2]", 4, 21)
+	check("nodes.node1.zones[2].name", 4, 22)
+	check("stacks.stack1.depends_on[0]", 8, 7)
+	check("stacks.stack1.depends_on[1]", 9, 7)
+	check("stacks.stack1.params.db", 12, 7)
+	check("stacks.stack1.params.db.port", 12, 12)
+	check("stacks.stack1.params.users[0]", 13, 15)
+	check("hooks.init.before[0]", 17, 7)
+	check("hooks.init.before[0].task", 17, 10)
+	check("hooks.init.before[0].prefix", 17, 23)
+	check("hooks.init.before[1].task", 18, 9)
+	check("qualifier", 19, 1)
+}
+
+func TestYamlPositionsTemplatedLines(t *testing.T) {
+	content := `nodes:
+  node1:
+{{ range $k, $v := .Vars.nodes }}
+    {{ $k }}: {{ $v }}
+{{ end }}
+    instances: 1
+stacks:
+  stack1:
+    depends_on:
+      - stack2
+      {{ if .Vars.monitoring }}- monitoring{{ end }}
+      - stack3
+`
+	pos := readYamlPositions([]byte(content))
+
+	// The lines starting with a template action are ignored
+	p, ok := pos["nodes.node1.instances"]
+	assert.True(t, ok)
+	assert.Equal(t, 6, p.line)
+	for path := range pos {
+		assert.NotContains(t, path, "{{")
+	}
+
+	// The generated elements get the position of their parent
+	p, ok = pos.locate("nodes.node1.generated")
+	assert.True(t, ok)
+	assert.Equal(t, 2, p.line)
+
+	// The items produced by a template are not counted
+	p, ok = pos["stacks.stack1.depends_on[1]"]
+	assert.True(t, ok)
+	assert.Equal(t, 12, p.line)
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// yamlField represents a key accepted into a yaml structure
+	yamlField struct {
+		// name is the yaml key of the field
+		name string
+		// fType is the type of the field
+		fType reflect.Type
+	}
+)
+
+// yamlFields returns all the keys accepted by the given yaml structure.
+//
+// The fields of the inline structures are returned as if they were defined
+// into the structure itself.
+func yamlFields(t reflect.Type) []yamlField {
+	res := make([]yamlField, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			// Unexported fields are ignored by the yaml unmarshaller
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		inline := false
+		if i := strings.Index(tag, ","); i >= 0 {
+			name = tag[:i]
+			for _, flag := range strings.Split(tag[i+1:], ",") {
+				if flag == "inline" {
+					inline = true
+				}
+			}
+		}
+		if inline && f.Type.Kind() == reflect.Struct {
+			res = append(res, yamlFields(f.Type)...)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		res = append(res, yamlField{name: name, fType: f.Type})
+	}
+	return res
+}
+
+// unknownYamlKeys returns a validation error, of the given type, for each key of the
+// yaml content which doesn't match the grammar of the targeted type
+func unknownYamlKeys(content []byte, target reflect.Type, location DescriptorLocation, t ErrorType) (ValidationErrors, error) {
+	vErrs := ValidationErrors{}
+	var tree interface{}
+	if err := yaml.Unmarshal(content, &tree); err != nil {
+		return vErrs, err
+	}
+	checkYamlKeys(tree, target, location, t, &vErrs)
+	return vErrs, nil
+}
+
+func checkYamlKeys(v interface{}, target reflect.Type, location DescriptorLocation, t ErrorType, vErrs *ValidationErrors) {
+	for target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	switch target.Kind() {
+	case reflect.Struct:
+		m, ok := stringKeyedYamlMap(v)
+		if !ok {
+			return
+		}
+		fields := make(map[string]reflect.Type)
+		for _, f := range yamlFields(target) {
+			fields[f.name] = f.fType
+		}
+		for _, k := range sortedKeys(m) {
+			if fType, ok := fields[k]; ok {
+				checkYamlKeys(m[k], fType, location.appendPath(k), t, vErrs)
+			} else {
+				vErrs.append(t, "unknown key: "+k, location.appendPath(k))
+			}
+		}
+	case reflect.Map:
+		m, ok := stringKeyedYamlMap(v)
+		if !ok || target.Elem().Kind() == reflect.Interface {
+			return
+		}
+		for _, k := range sortedKeys(m) {
+			checkYamlKeys(m[k], target.Elem(), location.appendPath(k), t, vErrs)
+		}
+	case reflect.Slice:
+		s, ok := v.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range s {
+			checkYamlKeys(item, target.Elem(), location.appendIndex(i), t, vErrs)
+		}
+	}
+}
+
+// stringKeyedYamlMap returns the content of a yaml map with its keys converted to strings
+func stringKeyedYamlMap(v interface{}) (map[string]interface{}, bool) {
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, false
+	}
+	res := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		res[fmt.Sprintf("%v", k)] = val
+	}
+	return res, true
+}
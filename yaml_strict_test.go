@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStrictUnknownKeys(t *testing.T) {
+	_, vErrs, e := ParseYamlDescriptorStrict(buildURL(t, "./testdata/yaml/strict/typos.yaml"), &TemplateContext{}, Error)
+	assert.Nil(t, e)
+	assert.True(t, vErrs.HasErrors())
+	assert.False(t, vErrs.HasWarnings())
+	assert.Equal(t, 6, len(vErrs.Errors))
+	assert.True(t, vErrs.contains(Error, "unknown key: descripton", "descripton"))
+	assert.True(t, vErrs.contains(Error, "unknown key: envs", "providers.aws.envs"))
+	assert.True(t, vErrs.contains(Error, "unknown key: instance", "nodes.node1.instance"))
+	assert.True(t, vErrs.contains(Error, "unknown key: label", "nodes.node1.label"))
+	assert.True(t, vErrs.contains(Error, "unknown key: depend_on", "stacks.stack1.depend_on"))
+	assert.True(t, vErrs.contains(Error, "unknown key: taks", "stacks.stack1.hooks.deploy.before[0].taks"))
+
+	located := vErrs.locate("unknown key: instance")
+	if assert.Equal(t, 1, len(located)) {
+		assert.Equal(t, 24, located[0].Location.Line)
+		assert.Equal(t, 5, located[0].Location.Column)
+	}
+}
+
+func TestParseStrictUnknownKeysAsWarnings(t *testing.T) {
+	yamlEnv, vErrs, e := ParseYamlDescriptorStrict(buildURL(t, "./testdata/yaml/strict/typos.yaml"), &TemplateContext{}, Warning)
+	assert.Nil(t, e)
+	assert.False(t, vErrs.HasErrors())
+	assert.True(t, vErrs.HasWarnings())
+	assert.Equal(t, 6, len(vErrs.Errors))
+
+	// The unknown keys are reported by the validation of the environment
+	env, e := CreateEnvironment("", yamlEnv, MainComponentId)
+	assert.Nil(t, e)
+	p, e := createPlatform(yamlEnv.Ekara)
+	assert.Nil(t, e)
+	env.ekara = &p
+	vErrs = env.Validate()
+	assert.True(t, vErrs.contains(Warning, "unknown key: depend_on", "stacks.stack1.depend_on"))
+}
+
+func TestParseNotStrict(t *testing.T) {
+	yamlEnv, e := ParseYamlDescriptor(buildURL(t, "./testdata/yaml/strict/typos.yaml"), &TemplateContext{})
+	assert.Nil(t, e)
+	env, e := CreateEnvironment("", yamlEnv, MainComponentId)
+	assert.Nil(t, e)
+	p, e := createPlatform(yamlEnv.Ekara)
+	assert.Nil(t, e)
+	env.ekara = &p
+	vErrs := env.Validate()
+	assert.Equal(t, 0, len(vErrs.locate("unknown key: depend_on")))
+}
+
+func TestParseStrictCompleteDescriptor(t *testing.T) {
+	_, vErrs, e := ParseYamlDescriptorStrict(buildURL(t, "./testdata/yaml/complete.yaml"), &TemplateContext{}, Error)
+	assert.Nil(t, e)
+	assert.Equal(t, 0, len(vErrs.Errors))
+}
@@ -42,3 +42,60 @@ func TestCreateEngineFromLocalWithData(t *testing.T) {
 	assert.Equal(t, "Name from data", yamlEnv.Name)
 	assert.Equal(t, "Description from data", yamlEnv.Description)
 }
+
+func TestCreateEngineFromBytes(t *testing.T) {
+	vars := CreateParameters(map[string]interface{}{
+		"info": map[string]string{
+			"name": "Name from data",
+		},
+	})
+	content := []byte("name: {{ .Vars.info.name }}\nnodes:\n  node1:\n    instances: 2\n")
+
+	yamlEnv, e := ParseYamlDescriptorBytes("in-memory", content, &TemplateContext{Vars: vars})
+	assert.Nil(t, e)
+	assert.Equal(t, "Name from data", yamlEnv.Name)
+
+	env, e := CreateEnvironment("in-memory", yamlEnv, MainComponentId)
+	assert.Nil(t, e)
+	l := env.NodeSets["node1"].location
+	assert.Equal(t, "in-memory", l.Descriptor)
+	assert.Equal(t, 3, l.Line)
+}
+
+func TestCreateEngineFromReader(t *testing.T) {
+	yamlEnv, e := ParseYamlDescriptorReader("in-memory", strings.NewReader("name: {{ .Vars.name }}\n"), &TemplateContext{Vars: CreateParameters(map[string]interface{}{"name": "from_reader"})})
+	assert.Nil(t, e)
+	assert.Equal(t, "from_reader", yamlEnv.Name)
+}
+
+func TestCreateEngineFromBytesStrict(t *testing.T) {
+	content := []byte("name: name_value\nnodes:\n  node1:\n    instance: 2\n")
+	_, vErrs, e := ParseYamlDescriptorBytesStrict("in-memory", content, &TemplateContext{}, Error)
+	assert.Nil(t, e)
+	assert.True(t, vErrs.contains(Error, "unknown key: instance", "nodes.node1.instance"))
+	located := vErrs.locate("unknown key: instance")
+	if assert.Equal(t, 1, len(located)) {
+		assert.Equal(t, "in-memory", located[0].Location.Descriptor)
+		assert.Equal(t, 4, located[0].Location.Line)
+	}
+
+	_, vErrs, e = ParseYamlDescriptorReaderStrict("in-memory", strings.NewReader(string(content)), &TemplateContext{}, Warning)
+	assert.Nil(t, e)
+	assert.False(t, vErrs.HasErrors())
+	assert.True(t, vErrs.contains(Warning, "unknown key: instance", "nodes.node1.instance"))
+}
+
+func TestCreateEngineFromBytesTemplateError(t *testing.T) {
+	_, e := ParseYamlDescriptorBytes("in-memory", []byte("name: {{ .Vars.name "), &TemplateContext{})
+	if assert.NotNil(t, e) {
+		assert.True(t, strings.Contains(e.Error(), "in-memory"))
+	}
+}
+
+func TestReferencesFromReader(t *testing.T) {
+	content := "ekara:\n  parent:\n    repository: {{ .Vars.parent }}\nstacks:\n  stack1:\n    component: stack1\n"
+	refs, e := ParseYamlDescriptorReferencesReader("in-memory", strings.NewReader(content), &TemplateContext{Vars: CreateParameters(map[string]interface{}{"parent": "some-org/parent"})})
+	assert.Nil(t, e)
+	assert.Equal(t, "some-org/parent", refs.Ekara.Parent.Repository)
+	assert.Contains(t, refs.StacksRefs, "stack1")
+}